@@ -0,0 +1,501 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decoder - fills Go values from nodes by their JSON names, remembers where each path came from
+type decoder struct {
+	errors    Errors
+	positions map[string]*node
+}
+
+func newDecoder() *decoder {
+	return &decoder{
+		errors:    Errors{},
+		positions: map[string]*node{},
+	}
+}
+
+func (thisRef *decoder) decode(n *node, target interface{}, path string) {
+	thisRef.decodeValue(n, reflect.ValueOf(target).Elem(), path)
+}
+
+func (thisRef *decoder) fail(n *node, path string, format string, v ...interface{}) {
+	thisRef.errors = append(thisRef.errors, &Error{
+		Line:    n.line,
+		Column:  n.column,
+		Path:    path,
+		Message: fmt.Sprintf(format, v...),
+	})
+}
+
+// failAt - for problems found after decoding, uses the closest path that has a position
+func (thisRef *decoder) failAt(path string, format string, v ...interface{}) {
+	err := &Error{
+		Path:    path,
+		Message: fmt.Sprintf(format, v...),
+	}
+
+	for p := path; len(p) > 0; {
+		if n, ok := thisRef.positions[p]; ok && n.line > 0 {
+			err.Line, err.Column = n.line, n.column
+			break
+		}
+
+		i := strings.LastIndexAny(p, ".[")
+		if i < 0 {
+			break
+		}
+		p = p[:i]
+	}
+
+	thisRef.errors = append(thisRef.errors, err)
+}
+
+func (thisRef *decoder) decodeValue(n *node, value reflect.Value, path string) {
+	thisRef.positions[path] = n
+
+	if n.kind == nodeNull {
+		return
+	}
+
+	// `RestartMode`, `OutputMode` and friends
+	if value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType) && value.Kind() != reflect.Struct {
+		if n.kind != nodeScalar {
+			thisRef.fail(n, path, "expected a value, got %s", n.describe())
+			return
+		}
+
+		if err := value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(fmt.Sprint(n.value))); err != nil {
+			thisRef.fail(n, path, "%s", err.Error())
+		}
+		return
+	}
+
+	if value.Type() == durationType {
+		thisRef.decodeDuration(n, value, path)
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		thisRef.decodeStruct(n, value, path)
+
+	case reflect.Map:
+		thisRef.decodeMap(n, value, path)
+
+	case reflect.Slice:
+		thisRef.decodeSlice(n, value, path)
+
+	case reflect.Ptr:
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		thisRef.decodeValue(n, value.Elem(), path)
+
+	case reflect.String:
+		if s, ok := n.value.(string); ok && n.kind == nodeScalar {
+			value.SetString(s)
+		} else {
+			thisRef.fail(n, path, "expected a string, got %s", n.describe())
+		}
+
+	case reflect.Bool:
+		if b, ok := n.value.(bool); ok && n.kind == nodeScalar {
+			value.SetBool(b)
+		} else {
+			thisRef.fail(n, path, "expected true or false, got %s", n.describe())
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := integerOf(n)
+		if !ok || value.OverflowInt(i) {
+			thisRef.fail(n, path, "expected an integer, got %s", n.describe())
+			return
+		}
+		value.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := integerOf(n)
+		if !ok || i < 0 || value.OverflowUint(uint64(i)) {
+			thisRef.fail(n, path, "expected a positive integer, got %s", n.describe())
+			return
+		}
+		value.SetUint(uint64(i))
+
+	case reflect.Float32, reflect.Float64:
+		switch v := n.value.(type) {
+		case float64:
+			value.SetFloat(v)
+		case int64:
+			value.SetFloat(float64(v))
+
+		default:
+			thisRef.fail(n, path, "expected a number, got %s", n.describe())
+		}
+
+	default:
+		thisRef.fail(n, path, "can not be set from a config file")
+	}
+}
+
+// decodeStruct - by JSON names, unknown names are errors, `json:"-"` fields are skipped
+func (thisRef *decoder) decodeStruct(n *node, value reflect.Value, path string) {
+	if n.kind != nodeMapping {
+		thisRef.fail(n, path, "expected a mapping, got %s", n.describe())
+		return
+	}
+
+	fields := map[string]int{}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		fields[name] = i
+	}
+
+	for i, key := range n.keys {
+		fieldIndex, ok := fields[key]
+		if !ok {
+			thisRef.fail(n.values[i], joinPath(path, key), "unknown field")
+			continue
+		}
+
+		thisRef.decodeValue(n.values[i], value.Field(fieldIndex), joinPath(path, key))
+	}
+}
+
+func (thisRef *decoder) decodeMap(n *node, value reflect.Value, path string) {
+	if n.kind != nodeMapping {
+		thisRef.fail(n, path, "expected a mapping, got %s", n.describe())
+		return
+	}
+
+	if value.Type().Key().Kind() != reflect.String {
+		thisRef.fail(n, path, "can not be set from a config file")
+		return
+	}
+
+	if value.IsNil() {
+		value.Set(reflect.MakeMap(value.Type()))
+	}
+
+	for i, key := range n.keys {
+		item := reflect.New(value.Type().Elem()).Elem()
+		thisRef.decodeValue(n.values[i], item, joinPath(path, key))
+		value.SetMapIndex(reflect.ValueOf(key).Convert(value.Type().Key()), item)
+	}
+}
+
+func (thisRef *decoder) decodeSlice(n *node, value reflect.Value, path string) {
+	// `environment` as a mapping
+	if value.Type().Elem().Kind() == reflect.String && n.kind == nodeMapping {
+		items := reflect.MakeSlice(value.Type(), 0, len(n.keys))
+		for i, key := range n.keys {
+			if n.values[i].kind != nodeScalar {
+				thisRef.fail(n.values[i], joinPath(path, key), "expected a value, got %s", n.values[i].describe())
+				continue
+			}
+
+			thisRef.positions[fmt.Sprintf("%s[%d]", path, i)] = n.values[i]
+			items = reflect.Append(items, reflect.ValueOf(fmt.Sprintf("%s=%v", key, n.values[i].value)))
+		}
+		value.Set(items)
+		return
+	}
+
+	if n.kind != nodeSequence {
+		thisRef.fail(n, path, "expected a list, got %s", n.describe())
+		return
+	}
+
+	items := reflect.MakeSlice(value.Type(), len(n.items), len(n.items))
+	for i, item := range n.items {
+		thisRef.decodeValue(item, items.Index(i), fmt.Sprintf("%s[%d]", path, i))
+	}
+	value.Set(items)
+}
+
+// decodeDuration - `"1m30s"`, or nanoseconds like `json.Marshal` writes them
+func (thisRef *decoder) decodeDuration(n *node, value reflect.Value, path string) {
+	switch v := n.value.(type) {
+	case string:
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			thisRef.fail(n, path, "expected a duration like 1m30s, got %s", n.describe())
+			return
+		}
+		value.SetInt(int64(duration))
+
+	case int64:
+		value.SetInt(v)
+
+	default:
+		thisRef.fail(n, path, "expected a duration like 1m30s, got %s", n.describe())
+	}
+}
+
+func integerOf(n *node) (int64, bool) {
+	switch v := n.value.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < math.MaxInt64 {
+			return int64(v), true
+		}
+	}
+
+	return 0, false
+}
+
+func joinPath(path string, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// validate - what decodes fine but can not run
+func (thisRef *decoder) validate(config Config) {
+	if len(config.Programs) == 0 {
+		thisRef.failAt("programs", "no programs")
+		return
+	}
+
+	tags := []string{}
+	for tag := range config.Programs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		template := config.Programs[tag]
+		path := joinPath("programs", tag)
+
+		if len(strings.TrimSpace(tag)) == 0 {
+			thisRef.failAt(path, "empty program name")
+		}
+
+		if len(strings.TrimSpace(template.Executable)) == 0 {
+			thisRef.failAt(joinPath(path, "executable"), "missing executable")
+		}
+
+		for i, env := range template.Environment {
+			if !strings.Contains(env, "=") {
+				thisRef.failAt(fmt.Sprintf("%s.environment[%d]", path, i), "expected KEY=value, got %s", env)
+			}
+		}
+
+		thisRef.validateRestartPolicy(template.RestartPolicy, joinPath(path, "restartPolicy"))
+		thisRef.validateStopStrategy(template.StopStrategy, joinPath(path, "stopStrategy"))
+
+		if len(template.StdinData) > 0 && len(template.StdinFile) > 0 {
+			thisRef.failAt(joinPath(path, "stdinFile"), "use either stdinData or stdinFile")
+		}
+
+		if template.MaxLineLength < 0 {
+			thisRef.failAt(joinPath(path, "maxLineLength"), "can not be negative")
+		}
+
+		thisRef.validateResourceLimits(template.ResourceLimits, joinPath(path, "resourceLimits"))
+		thisRef.validateCgroup(template.Cgroup, joinPath(path, "cgroup"))
+		thisRef.validateNamespaces(template.Namespaces, joinPath(path, "namespaces"))
+		thisRef.validateHealthCheck(template.Readiness, joinPath(path, "readiness"))
+		thisRef.validateHealthCheck(template.Liveness, joinPath(path, "liveness"))
+	}
+}
+
+func (thisRef *decoder) validateRestartPolicy(policy contracts.RestartPolicy, path string) {
+	if policy.MaxRestarts < 0 {
+		thisRef.failAt(joinPath(path, "maxRestarts"), "can not be negative")
+	}
+
+	if policy.Window < 0 {
+		thisRef.failAt(joinPath(path, "window"), "can not be negative")
+	}
+
+	backoffPath := joinPath(path, "backoff")
+	if policy.Backoff.InitialDelay < 0 {
+		thisRef.failAt(joinPath(backoffPath, "initialDelay"), "can not be negative")
+	}
+
+	if policy.Backoff.Multiplier < 0 {
+		thisRef.failAt(joinPath(backoffPath, "multiplier"), "can not be negative")
+	}
+
+	if policy.Backoff.MaxDelay < 0 {
+		thisRef.failAt(joinPath(backoffPath, "maxDelay"), "can not be negative")
+	}
+
+	if policy.Backoff.Jitter < 0 || policy.Backoff.Jitter > 1 {
+		thisRef.failAt(joinPath(backoffPath, "jitter"), "must be between 0 and 1")
+	}
+}
+
+func (thisRef *decoder) validateStopStrategy(strategy contracts.StopStrategy, path string) {
+	for i, step := range strategy.Steps {
+		stepPath := fmt.Sprintf("%s.steps[%d]", path, i)
+
+		if len(step.Signal) == 0 && len(step.Command) == 0 {
+			thisRef.failAt(stepPath, "needs a signal or a command")
+		}
+
+		if len(step.Signal) > 0 && len(step.Command) > 0 {
+			thisRef.failAt(stepPath, "use either a signal or a command")
+		}
+
+		if step.GracePeriod < 0 {
+			thisRef.failAt(joinPath(stepPath, "gracePeriod"), "can not be negative")
+		}
+	}
+}
+
+func (thisRef *decoder) validateResourceLimits(limits contracts.ResourceLimits, path string) {
+	resources := []struct {
+		name  string
+		limit *contracts.ResourceLimit
+	}{
+		{"openFiles", limits.OpenFiles},
+		{"coreSize", limits.CoreSize},
+		{"addressSpace", limits.AddressSpace},
+		{"cpuTime", limits.CPUTime},
+		{"processes", limits.Processes},
+	}
+
+	for _, r := range resources {
+		if r.limit != nil && r.limit.Soft > r.limit.Hard {
+			thisRef.failAt(joinPath(joinPath(path, r.name), "soft"), "can not be above the hard limit")
+		}
+	}
+}
+
+func (thisRef *decoder) validateCgroup(cgroup contracts.Cgroup, path string) {
+	hasLimits := cgroup.MemoryMax != 0 || cgroup.CPUMax != 0 || cgroup.PidsMax != 0 || cgroup.IOWeight != 0
+	if cgroup.IsEmpty() && (hasLimits || len(cgroup.Name) > 0) {
+		thisRef.failAt(joinPath(path, "parent"), "missing parent")
+	}
+
+	if strings.Contains(cgroup.Name, "/") {
+		thisRef.failAt(joinPath(path, "name"), "can not have /")
+	}
+
+	if cgroup.MemoryMax < 0 {
+		thisRef.failAt(joinPath(path, "memoryMax"), "can not be negative")
+	}
+
+	if cgroup.CPUMax < 0 {
+		thisRef.failAt(joinPath(path, "cpuMax"), "can not be negative")
+	}
+
+	if cgroup.PidsMax < 0 {
+		thisRef.failAt(joinPath(path, "pidsMax"), "can not be negative")
+	}
+
+	if cgroup.IOWeight < 0 || cgroup.IOWeight > 10000 {
+		thisRef.failAt(joinPath(path, "ioWeight"), "must be between 1 and 10000")
+	}
+}
+
+func (thisRef *decoder) validateNamespaces(namespaces contracts.Namespaces, path string) {
+	if len(namespaces.Hostname) > 0 && !namespaces.UTS {
+		thisRef.failAt(joinPath(path, "hostname"), "needs uts")
+	}
+
+	if len(namespaces.Hostname) > 64 {
+		thisRef.failAt(joinPath(path, "hostname"), "can not be longer than 64")
+	}
+
+	if len(namespaces.RootDirectory) > 0 && !filepath.IsAbs(namespaces.RootDirectory) {
+		thisRef.failAt(joinPath(path, "rootDirectory"), "must be absolute")
+	}
+
+	mappings := map[string][]contracts.IDMapping{
+		"uidMappings": namespaces.UIDMappings,
+		"gidMappings": namespaces.GIDMappings,
+	}
+	for _, name := range []string{"uidMappings", "gidMappings"} {
+		if len(mappings[name]) > 0 && !namespaces.User {
+			thisRef.failAt(joinPath(path, name), "needs user")
+		}
+
+		for i, mapping := range mappings[name] {
+			mappingPath := fmt.Sprintf("%s.%s[%d]", path, name, i)
+
+			if mapping.ContainerID < 0 || mapping.HostID < 0 {
+				thisRef.failAt(mappingPath, "IDs can not be negative")
+			}
+
+			if mapping.Size <= 0 {
+				thisRef.failAt(joinPath(mappingPath, "size"), "must be positive")
+			}
+		}
+	}
+}
+
+func (thisRef *decoder) validateHealthCheck(check contracts.HealthCheck, path string) {
+	switch check.Type {
+	case contracts.HealthCheckExec:
+		if len(check.Command) == 0 {
+			thisRef.failAt(joinPath(path, "command"), "missing command")
+		}
+	case contracts.HealthCheckTCP:
+		if len(check.Address) == 0 {
+			thisRef.failAt(joinPath(path, "address"), "missing address")
+		}
+	case contracts.HealthCheckHTTP:
+		if len(check.URL) == 0 {
+			thisRef.failAt(joinPath(path, "url"), "missing url")
+		}
+	case contracts.HealthCheckOutput:
+		if _, err := regexp.Compile(check.Pattern); err != nil {
+			thisRef.failAt(joinPath(path, "pattern"), "%s", err.Error())
+		}
+	}
+
+	durations := map[string]time.Duration{
+		"initialDelay": check.InitialDelay,
+		"interval":     check.Interval,
+		"timeout":      check.Timeout,
+		"startTimeout": check.StartTimeout,
+	}
+	for _, name := range []string{"initialDelay", "interval", "timeout", "startTimeout"} {
+		if durations[name] < 0 {
+			thisRef.failAt(joinPath(path, name), "can not be negative")
+		}
+	}
+
+	if check.SuccessThreshold < 0 {
+		thisRef.failAt(joinPath(path, "successThreshold"), "can not be negative")
+	}
+
+	if check.FailureThreshold < 0 {
+		thisRef.failAt(joinPath(path, "failureThreshold"), "can not be negative")
+	}
+}
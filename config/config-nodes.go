@@ -0,0 +1,363 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	toml "github.com/pelletier/go-toml"
+	yaml "gopkg.in/yaml.v3"
+)
+
+type nodeKind int
+
+const (
+	nodeNull nodeKind = iota
+	nodeScalar
+	nodeMapping
+	nodeSequence
+)
+
+// node - a parsed document value with its position, the same for every format
+type node struct {
+	kind   nodeKind
+	value  interface{} // scalars: string, bool, int64, float64
+	keys   []string    // mappings, in document order
+	values []*node     // mappings, one per key
+	items  []*node     // sequences
+	line   int
+	column int
+}
+
+func (thisRef *node) describe() string {
+	switch thisRef.kind {
+	case nodeMapping:
+		return "a mapping"
+	case nodeSequence:
+		return "a list"
+	case nodeNull:
+		return "null"
+
+	default:
+		return fmt.Sprintf("%T %v", thisRef.value, thisRef.value)
+	}
+}
+
+// parseNodes - the document as nodes, syntax errors carry their position
+func parseNodes(data []byte, format Format) (*node, error) {
+	switch format {
+	case FormatJSON:
+		return parseJSONNodes(data)
+	case FormatYAML:
+		return parseYAMLNodes(data)
+	case FormatTOML:
+		return parseTOMLNodes(data)
+
+	default:
+		return nil, fmt.Errorf("unknown config format [%s]", format)
+	}
+}
+
+//
+// JSON
+//
+
+func parseJSONNodes(data []byte) (*node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	parser := jsonParser{
+		data:    data,
+		decoder: decoder,
+	}
+
+	root, err := parser.parseValue()
+	if err != nil {
+		return nil, parser.locate(err)
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, &Error{Line: root.line, Column: root.column, Message: "more than one JSON document"}
+	}
+
+	return root, nil
+}
+
+type jsonParser struct {
+	data    []byte
+	decoder *json.Decoder
+}
+
+// position - where the next token starts, `json.Decoder` only tells where the previous one ended
+func (thisRef *jsonParser) position() (int, int) {
+	offset := int(thisRef.decoder.InputOffset())
+	for offset < len(thisRef.data) && bytes.IndexByte([]byte(" \t\r\n,:"), thisRef.data[offset]) >= 0 {
+		offset++
+	}
+
+	return lineAndColumn(thisRef.data, offset)
+}
+
+func (thisRef *jsonParser) parseValue() (*node, error) {
+	line, column := thisRef.position()
+
+	token, err := thisRef.decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &node{line: line, column: column}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if t == '{' {
+			n.kind = nodeMapping
+			for thisRef.decoder.More() {
+				keyLine, keyColumn := thisRef.position()
+
+				keyToken, err := thisRef.decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := thisRef.parseValue()
+				if err != nil {
+					return nil, err
+				}
+
+				// errors point at the key, it is where a human looks
+				value.line, value.column = keyLine, keyColumn
+				n.keys = append(n.keys, keyToken.(string))
+				n.values = append(n.values, value)
+			}
+		} else {
+			n.kind = nodeSequence
+			for thisRef.decoder.More() {
+				item, err := thisRef.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				n.items = append(n.items, item)
+			}
+		}
+
+		// closing delimiter
+		if _, err := thisRef.decoder.Token(); err != nil {
+			return nil, err
+		}
+
+	case json.Number:
+		n.kind = nodeScalar
+		if i, err := t.Int64(); err == nil {
+			n.value = i
+		} else if f, err := t.Float64(); err == nil {
+			n.value = f
+		} else {
+			n.value = t.String()
+		}
+
+	case nil:
+		n.kind = nodeNull
+
+	default:
+		n.kind = nodeScalar
+		n.value = t
+	}
+
+	return n, nil
+}
+
+func (thisRef *jsonParser) locate(err error) error {
+	offset := int(thisRef.decoder.InputOffset())
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		offset = int(syntaxErr.Offset)
+	}
+
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		offset = len(thisRef.data)
+		err = fmt.Errorf("unexpected end of JSON")
+	}
+
+	line, column := lineAndColumn(thisRef.data, offset)
+
+	return &Error{Line: line, Column: column, Message: err.Error()}
+}
+
+func lineAndColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+
+	return line, column
+}
+
+//
+// YAML
+//
+
+func parseYAMLNodes(data []byte) (*node, error) {
+	document := yaml.Node{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, &Error{Message: err.Error()} // YAML errors already say the line
+	}
+
+	if document.Kind == 0 {
+		return &node{kind: nodeMapping, line: 1, column: 1}, nil
+	}
+
+	return yamlToNode(&document)
+}
+
+func yamlToNode(yamlNode *yaml.Node) (*node, error) {
+	n := &node{line: yamlNode.Line, column: yamlNode.Column}
+
+	switch yamlNode.Kind {
+	case yaml.DocumentNode:
+		return yamlToNode(yamlNode.Content[0])
+
+	case yaml.AliasNode:
+		return yamlToNode(yamlNode.Alias)
+
+	case yaml.MappingNode:
+		n.kind = nodeMapping
+		for i := 0; i+1 < len(yamlNode.Content); i += 2 {
+			key, value := yamlNode.Content[i], yamlNode.Content[i+1]
+
+			// `<<: *defaults`
+			if key.Tag == "!!merge" {
+				merged, err := yamlToNode(value)
+				if err != nil {
+					return nil, err
+				}
+				if merged.kind != nodeMapping {
+					return nil, &Error{Line: key.Line, Column: key.Column, Message: "only mappings can be merged"}
+				}
+				n.keys = append(n.keys, merged.keys...)
+				n.values = append(n.values, merged.values...)
+				continue
+			}
+
+			v, err := yamlToNode(value)
+			if err != nil {
+				return nil, err
+			}
+
+			v.line, v.column = key.Line, key.Column
+			n.keys = append(n.keys, key.Value)
+			n.values = append(n.values, v)
+		}
+
+	case yaml.SequenceNode:
+		n.kind = nodeSequence
+		for _, item := range yamlNode.Content {
+			i, err := yamlToNode(item)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, i)
+		}
+
+	default:
+		var value interface{}
+		if err := yamlNode.Decode(&value); err != nil {
+			return nil, &Error{Line: yamlNode.Line, Column: yamlNode.Column, Message: err.Error()}
+		}
+
+		return scalarNode(n, value), nil
+	}
+
+	return n, nil
+}
+
+//
+// TOML
+//
+
+func parseTOMLNodes(data []byte) (*node, error) {
+	tree, err := toml.LoadBytes(data)
+	if err != nil {
+		return nil, &Error{Message: err.Error()} // TOML errors already say the line
+	}
+
+	return tomlTreeToNode(tree), nil
+}
+
+func tomlTreeToNode(tree *toml.Tree) *node {
+	position := tree.Position()
+	n := &node{kind: nodeMapping, line: position.Line, column: position.Col}
+
+	// TOML trees do not keep the order, the position does
+	keys := tree.Keys()
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := tree.GetPositionPath([]string{keys[i]}), tree.GetPositionPath([]string{keys[j]})
+		return pi.Line < pj.Line || (pi.Line == pj.Line && pi.Col < pj.Col)
+	})
+
+	for _, key := range keys {
+		position := tree.GetPositionPath([]string{key})
+
+		value := tomlValueToNode(tree.GetPath([]string{key}))
+		value.line, value.column = position.Line, position.Col
+
+		n.keys = append(n.keys, key)
+		n.values = append(n.values, value)
+	}
+
+	return n
+}
+
+func tomlValueToNode(value interface{}) *node {
+	switch v := value.(type) {
+	case *toml.Tree:
+		return tomlTreeToNode(v)
+
+	case []*toml.Tree:
+		n := &node{kind: nodeSequence}
+		for _, tree := range v {
+			n.items = append(n.items, tomlTreeToNode(tree))
+		}
+		return n
+
+	case []interface{}:
+		n := &node{kind: nodeSequence}
+		for _, item := range v {
+			n.items = append(n.items, tomlValueToNode(item))
+		}
+		return n
+
+	default:
+		return scalarNode(&node{}, v)
+	}
+}
+
+// scalarNode - one set of scalar types, whatever the format decoded
+func scalarNode(n *node, value interface{}) *node {
+	n.kind = nodeScalar
+
+	switch v := value.(type) {
+	case nil:
+		n.kind = nodeNull
+	case int:
+		n.value = int64(v)
+	case uint64:
+		n.value = strconv.FormatUint(v, 10)
+	case float32:
+		n.value = float64(v)
+	case time.Time:
+		n.value = v.Format(time.RFC3339Nano)
+	case string, bool, int64, float64:
+		n.value = v
+
+	default:
+		n.value = fmt.Sprint(v)
+	}
+
+	return n
+}
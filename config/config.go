@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+const logID = "PROCESS-CONFIG"
+
+// Format - syntax of a config document
+type Format int
+
+// FormatJSON -
+const (
+	FormatJSON Format = iota // 0 -> `.json`
+	FormatYAML               // 1 -> `.yaml`, `.yml`
+	FormatTOML               // 2 -> `.toml`
+)
+
+// String - stringer interface
+func (thisRef Format) String() string {
+	switch thisRef {
+	case FormatJSON:
+		return "json"
+	case FormatYAML:
+		return "yaml"
+	case FormatTOML:
+		return "toml"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// Config - named programs, the names become the monitor tags
+//
+// Fields use the JSON names of `contracts.ProcessTemplate`, durations can be written as `"1m30s"`,
+// `environment` can be a list of `KEY=value` or a mapping
+type Config struct {
+	Programs map[string]contracts.ProcessTemplate `json:"programs"`
+}
+
+// Error - a problem found in a config document, and where
+type Error struct {
+	File    string
+	Line    int // 0 when the parser does not tell, the message then has it
+	Column  int
+	Path    string // like `programs.web.restartPolicy.mode`
+	Message string
+}
+
+// Error - error interface
+func (thisRef *Error) Error() string {
+	location := thisRef.File
+	if thisRef.Line > 0 {
+		location = fmt.Sprintf("%d:%d", thisRef.Line, thisRef.Column)
+		if len(thisRef.File) > 0 {
+			location = thisRef.File + ":" + location
+		}
+	}
+
+	message := thisRef.Message
+	if len(thisRef.Path) > 0 {
+		message = thisRef.Path + ": " + message
+	}
+
+	if len(location) == 0 {
+		return message
+	}
+
+	return location + ": " + message
+}
+
+// Errors - every problem found in a config document
+type Errors []*Error
+
+// Error - error interface
+func (thisRef Errors) Error() string {
+	messages := []string{}
+	for _, err := range thisRef {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+// FormatFromPath - by file extension
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+
+	default:
+		return FormatJSON, fmt.Errorf("%s: unknown config format, use .json, .yaml, .yml or .toml", path)
+	}
+}
+
+// Load - reads and validates a config file, the format comes from the extension
+func Load(path string) (Config, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	config, err := Parse(data, format)
+	if err != nil {
+		return Config{}, withFile(err, path)
+	}
+
+	return config, nil
+}
+
+// Parse - parses and validates a config document, errors are `*Error` or `Errors`
+func Parse(data []byte, format Format) (Config, error) {
+	root, err := parseNodes(data, format)
+	if err != nil {
+		return Config{}, err
+	}
+
+	decoder := newDecoder()
+
+	config := Config{}
+	decoder.decode(root, &config, "")
+
+	if len(decoder.errors) == 0 {
+		decoder.validate(config)
+	}
+
+	if len(decoder.errors) > 0 {
+		return Config{}, decoder.errors
+	}
+
+	return config, nil
+}
+
+// Spawn - spawns every program with its name as tag, in dependency order
+func Spawn(monitor contracts.Monitor, config Config) error {
+	if err := monitor.SpawnAll(config.Programs); err != nil {
+		logging.Errorf("%s: spawn-FAIL, %s", logID, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// LoadAndSpawn - `Load` then `Spawn`
+func LoadAndSpawn(monitor contracts.Monitor, path string) (Config, error) {
+	config, err := Load(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return config, Spawn(monitor, config)
+}
+
+func withFile(err error, path string) error {
+	switch e := err.(type) {
+	case *Error:
+		e.File = path
+	case Errors:
+		for _, item := range e {
+			item.File = path
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,315 @@
+package tests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codemodify/systemkit-processes/config"
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+const configYAML = `
+programs:
+  web:
+    executable: /usr/bin/web
+    args: ["--port", "8080"]
+    environment:
+      PORT: 8080
+      MODE: production
+    restartPolicy:
+      mode: on-failure
+      maxRestarts: 5
+      window: 1m
+      backoff:
+        initialDelay: 500ms
+        jitter: 0.2
+    stopStrategy:
+      steps:
+        - signal: SIGTERM
+          gracePeriod: 10s
+        - signal: SIGKILL
+  worker:
+    executable: /usr/bin/worker
+    stdinData: hello
+`
+
+const configJSON = `{
+  "programs": {
+    "web": {
+      "executable": "/usr/bin/web",
+      "args": ["--port", "8080"],
+      "environment": ["PORT=8080", "MODE=production"],
+      "restartPolicy": {
+        "mode": "on-failure",
+        "maxRestarts": 5,
+        "window": "1m",
+        "backoff": { "initialDelay": 500000000, "jitter": 0.2 }
+      },
+      "stopStrategy": {
+        "steps": [{ "signal": "SIGTERM", "gracePeriod": "10s" }, { "signal": "SIGKILL" }]
+      }
+    },
+    "worker": { "executable": "/usr/bin/worker", "stdinData": "hello" }
+  }
+}`
+
+const configTOML = `
+[programs.web]
+executable = "/usr/bin/web"
+args = ["--port", "8080"]
+environment = { PORT = 8080, MODE = "production" }
+
+[programs.web.restartPolicy]
+mode = "on-failure"
+maxRestarts = 5
+window = "1m"
+backoff = { initialDelay = "500ms", jitter = 0.2 }
+
+[[programs.web.stopStrategy.steps]]
+signal = "SIGTERM"
+gracePeriod = "10s"
+
+[[programs.web.stopStrategy.steps]]
+signal = "SIGKILL"
+
+[programs.worker]
+executable = "/usr/bin/worker"
+stdinData = "hello"
+`
+
+func TestParseFormats(t *testing.T) {
+	expected := config.Config{
+		Programs: map[string]contracts.ProcessTemplate{
+			"web": {
+				Executable:  "/usr/bin/web",
+				Args:        []string{"--port", "8080"},
+				Environment: []string{"PORT=8080", "MODE=production"},
+				RestartPolicy: contracts.RestartPolicy{
+					Mode:        contracts.RestartOnFailure,
+					MaxRestarts: 5,
+					Window:      time.Minute,
+					Backoff: contracts.BackoffPolicy{
+						InitialDelay: 500 * time.Millisecond,
+						Jitter:       0.2,
+					},
+				},
+				StopStrategy: contracts.StopStrategy{
+					Steps: []contracts.StopStep{
+						{Signal: "SIGTERM", GracePeriod: 10 * time.Second},
+						{Signal: "SIGKILL"},
+					},
+				},
+			},
+			"worker": {
+				Executable: "/usr/bin/worker",
+				StdinData:  []byte("hello"),
+			},
+		},
+	}
+
+	for format, document := range map[config.Format]string{
+		config.FormatYAML: configYAML,
+		config.FormatJSON: configJSON,
+		config.FormatTOML: configTOML,
+	} {
+		parsed, err := config.Parse([]byte(document), format)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+
+		// TOML inline tables do not keep the order
+		if format == config.FormatTOML {
+			web := parsed.Programs["web"]
+			web.Environment = expected.Programs["web"].Environment
+			parsed.Programs["web"] = web
+		}
+
+		if !reflect.DeepEqual(parsed, expected) {
+			t.Fatalf("%s: got %+v", format, parsed)
+		}
+	}
+
+	// written back as plain text, not as base64, and read again the same
+	data, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(string(data), `"stdinData":"hello"`) {
+		t.Fatalf("expected plain stdinData, got %s", data)
+	}
+
+	parsed, err := config.Parse(data, config.FormatJSON)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(parsed.Programs["worker"].StdinData, expected.Programs["worker"].StdinData) {
+		t.Fatalf("got stdinData %q", parsed.Programs["worker"].StdinData)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		format   config.Format
+		document string
+		expected []string
+	}{
+		{
+			format: config.FormatYAML,
+			document: `
+programs:
+  web:
+    executable: /usr/bin/web
+    restartPolicy:
+      mode: sometimes
+      window: soon
+    colour: blue
+`,
+			expected: []string{
+				"6:7: programs.web.restartPolicy.mode: unknown restart mode [sometimes]",
+				"7:7: programs.web.restartPolicy.window: expected a duration like 1m30s, got string soon",
+				"8:5: programs.web.colour: unknown field",
+			},
+		},
+		{
+			format: config.FormatYAML,
+			document: `
+programs:
+  web:
+    args: [a]
+    restartPolicy:
+      backoff:
+        jitter: 2
+`,
+			expected: []string{
+				"3:3: programs.web.executable: missing executable",
+				"7:9: programs.web.restartPolicy.backoff.jitter: must be between 0 and 1",
+			},
+		},
+		{
+			format:   config.FormatJSON,
+			document: "{\n  \"programs\": {\n    \"web\": { \"executable\": 42 }\n  }\n}",
+			expected: []string{
+				"3:14: programs.web.executable: expected a string, got int64 42",
+			},
+		},
+		{
+			format:   config.FormatJSON,
+			document: "{\n  \"programs\": {\n    \"web\": { \"executable\" \"x\" }\n  }\n}",
+			expected: []string{
+				"3:",
+			},
+		},
+		{
+			format:   config.FormatTOML,
+			document: "[programs.web]\nexecutable = \"/bin/true\"\n\n[programs.web.stopStrategy]\nsteps = [{ gracePeriod = \"1s\" }]\n",
+			expected: []string{
+				"4:1: programs.web.stopStrategy.steps[0]: needs a signal or a command", // inline tables have no position, their table has
+			},
+		},
+		{
+			format: config.FormatYAML,
+			document: `
+programs:
+  web:
+    executable: /usr/bin/web
+    readiness:
+      type: http
+      interval: -1s
+    liveness:
+      type: output
+      pattern: "ready("
+`,
+			expected: []string{
+				"5:5: programs.web.readiness.url: missing url",
+				"7:7: programs.web.readiness.interval: can not be negative",
+				"10:7: programs.web.liveness.pattern: error parsing regexp",
+			},
+		},
+		{
+			format:   config.FormatJSON,
+			document: "{\n  \"programs\": {\n    \"web\": {\n      \"executable\": \"/usr/bin/web\",\n      \"resourceLimits\": { \"openFiles\": { \"soft\": 2048, \"hard\": 1024 } }\n    }\n  }\n}",
+			expected: []string{
+				"5:42: programs.web.resourceLimits.openFiles.soft: can not be above the hard limit",
+			},
+		},
+		{
+			format: config.FormatYAML,
+			document: `
+programs:
+  web:
+    executable: /usr/bin/web
+    cgroup:
+      memoryMax: 1048576
+      ioWeight: 20000
+`,
+			expected: []string{
+				"5:5: programs.web.cgroup.parent: missing parent",
+				"7:7: programs.web.cgroup.ioWeight: must be between 1 and 10000",
+			},
+		},
+		{
+			format: config.FormatYAML,
+			document: `
+programs:
+  web:
+    executable: /usr/bin/web
+    namespaces:
+      hostname: sandbox
+      uidMappings:
+        - containerID: 0
+          hostID: 1000
+`,
+			expected: []string{
+				"6:7: programs.web.namespaces.hostname: needs uts",
+				"7:7: programs.web.namespaces.uidMappings: needs user",
+				"programs.web.namespaces.uidMappings[0].size: must be positive",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		_, err := config.Parse([]byte(test.document), test.format)
+		if err == nil {
+			t.Fatalf("#%d: expected errors", i)
+		}
+
+		for _, expected := range test.expected {
+			if !strings.Contains(err.Error(), expected) {
+				t.Fatalf("#%d: expected %q in:\n%s", i, expected, err.Error())
+			}
+		}
+	}
+}
+
+func TestLoadAndSpawn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "programs.yml")
+	ioutil.WriteFile(path, []byte(`
+programs:
+  sleeper:
+    executable: sleep
+    args: ["5"]
+`), 0644)
+
+	monitor := procMon.New()
+
+	if _, err := config.LoadAndSpawn(monitor, path); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer monitor.Stop("sleeper")
+
+	if !monitor.GetProcess("sleeper").IsRunning() {
+		t.Fatalf("sleeper is not running, tags %v", monitor.GetAllTags())
+	}
+
+	// errors name the file
+	ioutil.WriteFile(path, []byte("programs:\n  broken: {}\n"), 0644)
+	if _, err := config.Load(path); err == nil || !strings.HasPrefix(err.Error(), path+":2:3: ") {
+		t.Fatalf("bad error %v", err)
+	}
+}
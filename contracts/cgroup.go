@@ -0,0 +1,36 @@
+package contracts
+
+import "errors"
+
+// ErrCgroupNotAvailable -
+var ErrCgroupNotAvailable = errors.New("ErrCgroupNotAvailable")
+
+// Cgroup - a cgroup v2 child group each run of the process is started in, Linux only
+//
+// The group is created when the process starts, then removed once it exits, with whatever is left in it
+type Cgroup struct {
+	Parent    string  `json:"parent"`    // folder of a delegated cgroup v2 group, like `/sys/fs/cgroup/my.slice`, empty means no cgroup
+	Name      string  `json:"name"`      // folder created under `Parent`, it must not exist, empty means the monitor tag or the executable name
+	MemoryMax int64   `json:"memoryMax"` // `memory.max`, bytes, 0 means no limit
+	CPUMax    float64 `json:"cpuMax"`    // `cpu.max`, CPUs, 1.5 means 150ms every 100ms, 0 means no limit
+	PidsMax   int64   `json:"pidsMax"`   // `pids.max`, 0 means no limit
+	IOWeight  int     `json:"ioWeight"`  // `io.weight`, 1 to 10000, 0 keeps the default of 100
+}
+
+// IsEmpty - `true` if no group is asked for
+func (thisRef Cgroup) IsEmpty() bool {
+	return len(thisRef.Parent) == 0
+}
+
+// CgroupStats - usage of the whole group, the process and everything it started
+type CgroupStats struct {
+	Path          string `json:"path"`
+	MemoryCurrent uint64 `json:"memoryCurrent"` // bytes
+	MemoryPeak    uint64 `json:"memoryPeak"`    // bytes, 0 on kernels before 5.19
+	CPUUsageUsec  uint64 `json:"cpuUsageUsec"`
+	CPUUserUsec   uint64 `json:"cpuUserUsec"`
+	CPUSystemUsec uint64 `json:"cpuSystemUsec"`
+	PidsCurrent   uint64 `json:"pidsCurrent"`
+	OOMEvents     uint64 `json:"oomEvents"`     // times `memory.max` was hit and reclaim failed
+	OOMKillEvents uint64 `json:"oomKillEvents"` // processes of the group killed by the OOM killer
+}
@@ -0,0 +1,15 @@
+package contracts
+
+import "errors"
+
+// ErrUnknownUser -
+var ErrUnknownUser = errors.New("ErrUnknownUser")
+
+// ErrUnknownGroup -
+var ErrUnknownGroup = errors.New("ErrUnknownGroup")
+
+// ErrCredentialsNotPermitted - switching user or group needs root or `CAP_SETUID`/`CAP_SETGID`
+var ErrCredentialsNotPermitted = errors.New("ErrCredentialsNotPermitted")
+
+// ErrCredentialsNotSupported -
+var ErrCredentialsNotSupported = errors.New("ErrCredentialsNotSupported")
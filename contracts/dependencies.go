@@ -0,0 +1,12 @@
+package contracts
+
+import "errors"
+
+// ErrDependencyCycle -
+var ErrDependencyCycle = errors.New("ErrDependencyCycle")
+
+// ErrDependencyMissing -
+var ErrDependencyMissing = errors.New("ErrDependencyMissing")
+
+// ErrDependencyFailed -
+var ErrDependencyFailed = errors.New("ErrDependencyFailed")
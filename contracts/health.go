@@ -0,0 +1,130 @@
+package contracts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNotReady -
+var ErrNotReady = errors.New("ErrNotReady")
+
+// ErrInvalidHealthCheck -
+var ErrInvalidHealthCheck = errors.New("ErrInvalidHealthCheck")
+
+// HealthCheckType - how a health check probes the process
+type HealthCheckType int
+
+// HealthCheckNone -
+const (
+	HealthCheckNone   HealthCheckType = iota // 0 -> no check
+	HealthCheckExec                          // 1 -> runs `Command`, passes on exit code 0
+	HealthCheckTCP                           // 2 -> connects to `Address`
+	HealthCheckHTTP                          // 3 -> GET `URL`, passes on 2xx and 3xx
+	HealthCheckOutput                        // 4 -> passes if STDOUT had a line matching `Pattern` since the previous check
+)
+
+// String - stringer interface
+func (thisRef HealthCheckType) String() string {
+	switch thisRef {
+	case HealthCheckNone:
+		return "none"
+	case HealthCheckExec:
+		return "exec"
+	case HealthCheckTCP:
+		return "tcp"
+	case HealthCheckHTTP:
+		return "http"
+	case HealthCheckOutput:
+		return "output"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - checks are written as `"http"` in JSON
+func (thisRef HealthCheckType) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// UnmarshalText - allows the check to be read as `"tcp"` from JSON
+func (thisRef *HealthCheckType) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "", "none":
+		*thisRef = HealthCheckNone
+	case "exec":
+		*thisRef = HealthCheckExec
+	case "tcp":
+		*thisRef = HealthCheckTCP
+	case "http":
+		*thisRef = HealthCheckHTTP
+	case "output":
+		*thisRef = HealthCheckOutput
+
+	default:
+		return fmt.Errorf("unknown health check [%s]", string(text))
+	}
+
+	return nil
+}
+
+// HealthCheck - a probe run by the monitor while the process runs
+type HealthCheck struct {
+	Type             HealthCheckType `json:"type"`
+	Command          []string        `json:"command"` // exec, `$MAINPID` is replaced with the PID and also set as env
+	Address          string          `json:"address"` // tcp, like `127.0.0.1:5432`
+	URL              string          `json:"url"`     // http, like `http://127.0.0.1:8080/healthz`
+	Pattern          string          `json:"pattern"` // output, a regular expression
+	InitialDelay     time.Duration   `json:"initialDelay"`
+	Interval         time.Duration   `json:"interval"`         // 0 means 10s
+	Timeout          time.Duration   `json:"timeout"`          // 0 means 1s
+	SuccessThreshold int             `json:"successThreshold"` // consecutive passes to become healthy, 0 means 1
+	FailureThreshold int             `json:"failureThreshold"` // consecutive failures to become unhealthy, 0 means 3
+	StartTimeout     time.Duration   `json:"startTimeout"`     // readiness, how long dependents wait for it, 0 means 1m
+	RestartOnFailure bool            `json:"restartOnFailure"` // liveness, the monitor restarts the process once it is unhealthy, within `MaxRestarts` and the backoff of the restart policy
+}
+
+// HealthStatus - health of a monitored process
+type HealthStatus int
+
+// HealthUnknown -
+const (
+	HealthUnknown   HealthStatus = iota // 0 -> not running
+	HealthStarting                      // 1 -> running, the readiness check did not pass yet
+	HealthHealthy                       // 2 -> ready, the liveness check passes
+	HealthUnhealthy                     // 3 -> the liveness check failed `FailureThreshold` times in a row
+)
+
+// String - stringer interface
+func (thisRef HealthStatus) String() string {
+	switch thisRef {
+	case HealthUnknown:
+		return "unknown"
+	case HealthStarting:
+		return "starting"
+	case HealthHealthy:
+		return "healthy"
+	case HealthUnhealthy:
+		return "unhealthy"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - statuses are written as `"healthy"` in JSON
+func (thisRef HealthStatus) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// HealthState - health of a tag as seen by the monitor
+type HealthState struct {
+	Status               HealthStatus `json:"status"`
+	Ready                bool         `json:"ready"` // the readiness check passed for the current run, or there is none
+	ConsecutiveFailures  int          `json:"consecutiveFailures"`
+	ConsecutiveSuccesses int          `json:"consecutiveSuccesses"`
+	LastCheckAt          time.Time    `json:"lastCheckAt"`
+	LastError            string       `json:"lastError"`
+}
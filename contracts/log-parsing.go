@@ -0,0 +1,79 @@
+package contracts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+)
+
+// LogFormat - how output lines are parsed into log records
+type LogFormat int
+
+// LogFormatNone -
+const (
+	LogFormatNone   LogFormat = iota // 0 -> not parsed
+	LogFormatJSON                    // 1 -> a JSON object per line
+	LogFormatLogfmt                  // 2 -> `key=value` pairs per line
+)
+
+// String - stringer interface
+func (thisRef LogFormat) String() string {
+	switch thisRef {
+	case LogFormatNone:
+		return "none"
+	case LogFormatJSON:
+		return "json"
+	case LogFormatLogfmt:
+		return "logfmt"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - formats are written as `"logfmt"` in JSON
+func (thisRef LogFormat) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// UnmarshalText - allows the format to be read as `"json"` from JSON
+func (thisRef *LogFormat) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "", "none":
+		*thisRef = LogFormatNone
+	case "json":
+		*thisRef = LogFormatJSON
+	case "logfmt":
+		*thisRef = LogFormatLogfmt
+
+	default:
+		return fmt.Errorf("unknown log format [%s]", string(text))
+	}
+
+	return nil
+}
+
+// LogRecord - an output line parsed into a structured record
+type LogRecord struct {
+	Tag     string                 `json:"tag"`
+	Stream  OutputStream           `json:"stream"`
+	Time    time.Time              `json:"time"`    // from the record, or when the line was read
+	Level   logging.LogType        `json:"level"`   // `TypeInfo` when the record has none
+	Message string                 `json:"message"` // the whole line when it could not be parsed
+	Fields  map[string]interface{} `json:"fields"`  // everything except the level, message and time
+	Parsed  bool                   `json:"parsed"`  // false if the line was not in the expected format
+}
+
+// LogRecordDelegate -
+type LogRecordDelegate func(params interface{}, logRecord LogRecord)
+
+// LogParsing - parses STDOUT and STDERR lines, not used in `OutputModeRaw`
+type LogParsing struct {
+	Format           LogFormat         `json:"format"`
+	Tag              string            `json:"tag"`              // set on every record, the monitor uses the process tag when empty
+	ForwardToLogging bool              `json:"forwardToLogging"` // log the records through `systemkit-logging`
+	OnRecord         LogRecordDelegate `json:"-"`                // called with every record
+	OnRecordParams   interface{}       `json:"-"`
+}
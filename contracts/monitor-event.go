@@ -0,0 +1,71 @@
+package contracts
+
+import (
+	"fmt"
+	"time"
+)
+
+// MonitorEventType - what happened to a monitored process
+type MonitorEventType int
+
+// MonitorEventSpawned -
+const (
+	MonitorEventSpawned       MonitorEventType = iota // 0 -> added to the monitor
+	MonitorEventStarted                               // 1 -> started, has a PID
+	MonitorEventStartFailed                           // 2 -> start failed, see `Error`
+	MonitorEventStopping                              // 3 -> `Monitor.Stop` began stopping it
+	MonitorEventStopped                               // 4 -> `Monitor.Stop` finished
+	MonitorEventExited                                // 5 -> the process exited, see `ExitStatus`
+	MonitorEventRestarted                             // 6 -> started again by the restart policy
+	MonitorEventRemoved                               // 7 -> removed from the monitor
+	MonitorEventHealthChanged                         // 8 -> the health status changed, see `Health`
+	MonitorEventOOMKilled                             // 9 -> exited and the OOM killer hit its cgroup, sent after `MonitorEventExited`
+)
+
+// String - stringer interface
+func (thisRef MonitorEventType) String() string {
+	switch thisRef {
+	case MonitorEventSpawned:
+		return "spawned"
+	case MonitorEventStarted:
+		return "started"
+	case MonitorEventStartFailed:
+		return "start-failed"
+	case MonitorEventStopping:
+		return "stopping"
+	case MonitorEventStopped:
+		return "stopped"
+	case MonitorEventExited:
+		return "exited"
+	case MonitorEventRestarted:
+		return "restarted"
+	case MonitorEventRemoved:
+		return "removed"
+	case MonitorEventHealthChanged:
+		return "health-changed"
+	case MonitorEventOOMKilled:
+		return "oom-killed"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - events are written as `"start-failed"` in JSON
+func (thisRef MonitorEventType) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// MonitorEvent - a state change of a monitored process
+type MonitorEvent struct {
+	Type       MonitorEventType `json:"type"`
+	Tag        string           `json:"tag"`
+	ProcessID  int              `json:"processID"`
+	Time       time.Time        `json:"time"`
+	StartedAt  time.Time        `json:"startedAt"`
+	StoppedAt  time.Time        `json:"stoppedAt"`
+	ExitStatus ExitStatus       `json:"exitStatus"` // set for `MonitorEventExited` and `MonitorEventOOMKilled`
+	Error      string           `json:"error"`      // set for `MonitorEventStartFailed`
+	Health     HealthState      `json:"health"`     // set for `MonitorEventHealthChanged`
+	Process    RuntimeProcess   `json:"process"`    // snapshot taken when the event was raised
+}
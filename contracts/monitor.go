@@ -1,17 +1,33 @@
 package contracts
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Monitor - process monitor
 type Monitor interface {
 	Spawn(process ProcessTemplate) (string, error)
 	SpawnWithTag(process ProcessTemplate, tag string) error
+	SpawnAll(processes map[string]ProcessTemplate) error
 	Start(tag string) error
 	Stop(tag string) error
 	StopWithTimeout(tag string, attempts int, waitTimeout time.Duration) error
 	Restart(tag string) error
 	StopAllInParallel()
+	StartAll() error
+	StopAll()
 	GetProcess(tag string) RuningProcess
 	RemoveFromMonitor(tag string)
 	GetAllTags() []string
+	GetRestartState(tag string) RestartState
+	GetHealth(tag string) HealthState
+	WaitReady(ctx context.Context, tag string) error
+	SampleStats(interval time.Duration, historySize int)
+	GetStats(tag string) []ProcessStats
+	EnableSubreaper() error
+	EnableReaper() error
+	Events() (<-chan MonitorEvent, func())
+	PlanReload(desired map[string]ProcessTemplate) ReloadPlan
+	Reload(desired map[string]ProcessTemplate) (ReloadPlan, error)
 }
@@ -0,0 +1,47 @@
+package contracts
+
+import "errors"
+
+// ErrNamespacesNotSupported -
+var ErrNamespacesNotSupported = errors.New("ErrNamespacesNotSupported")
+
+// ErrNamespacesNotPermitted - new namespaces need root or `CAP_SYS_ADMIN`, unless `User` is set too
+var ErrNamespacesNotPermitted = errors.New("ErrNamespacesNotPermitted")
+
+// Namespaces - Linux namespaces the process is started in, a light sandbox without a container runtime
+//
+// Go can't run code between `fork()` and `exec()`, there is no `pivot_root()` and the process sees the mounts of
+// the host, including the host `/proc`, until it mounts its own
+type Namespaces struct {
+	PID     bool `json:"pid"`     // the process is PID 1 of a new PID namespace, its descendants die with it
+	Mount   bool `json:"mount"`   // mounts made by the process stay in it, unless they are shared with the host
+	Network bool `json:"network"` // no network, only a loopback interface that is down
+	UTS     bool `json:"uts"`     // own hostname, needed for `Hostname`
+	IPC     bool `json:"ipc"`     // own System V IPC and POSIX message queues
+	User    bool `json:"user"`    // own users and groups, lets a non root caller use the other namespaces
+
+	UIDMappings []IDMapping `json:"uidMappings"` // with `User`, empty maps root inside to the current user, the process starts as the first one
+	GIDMappings []IDMapping `json:"gidMappings"` // with `User`, empty maps root inside to the current group
+
+	RootDirectory string `json:"rootDirectory"` // `chroot()` to it, `WorkingDirectory` is then inside it
+	Hostname      string `json:"hostname"`      // with `UTS`, needs root even with `User`
+}
+
+// IDMapping - `Size` IDs starting at `ContainerID` inside the user namespace are `HostID` and up outside
+type IDMapping struct {
+	ContainerID int `json:"containerID"`
+	HostID      int `json:"hostID"`
+	Size        int `json:"size"`
+}
+
+// IsEmpty - `true` if the process runs in the namespaces of the caller
+func (thisRef Namespaces) IsEmpty() bool {
+	return !thisRef.PID &&
+		!thisRef.Mount &&
+		!thisRef.Network &&
+		!thisRef.UTS &&
+		!thisRef.IPC &&
+		!thisRef.User &&
+		len(thisRef.RootDirectory) == 0 &&
+		len(thisRef.Hostname) == 0
+}
@@ -0,0 +1,9 @@
+package contracts
+
+import "errors"
+
+// ErrParentDeathSignalNotSupported -
+var ErrParentDeathSignalNotSupported = errors.New("ErrParentDeathSignalNotSupported")
+
+// ErrSubreaperNotSupported -
+var ErrSubreaperNotSupported = errors.New("ErrSubreaperNotSupported")
@@ -0,0 +1,22 @@
+package contracts
+
+import (
+	"time"
+)
+
+// OutputFile - a file that output is appended to, rotated by size and age
+type OutputFile struct {
+	Path            string        `json:"path"`
+	MaxSize         int64         `json:"maxSize"`         // bytes, rotate before the file grows past it, 0 means no limit
+	MaxAge          time.Duration `json:"maxAge"`          // rotate once the file was written this long, 0 means no limit
+	MaxFiles        int           `json:"maxFiles"`        // rotated files kept, 0 means all
+	Compress        bool          `json:"compress"`        // gzip rotated files, in the background, `Close` waits for it
+	TimestampFormat string        `json:"timestampFormat"` // prefix each line with the time in this `time` layout, empty means no prefix
+	Raw             bool          `json:"raw"`             // write the output as is, no line breaks nor timestamps added, set for `OutputModeRaw` templates
+}
+
+// OutputSink - pass `OnOutput` to `OnStdOut` or `OnStdErr`
+type OutputSink interface {
+	OnOutput(params interface{}, outputData []byte)
+	Close() error
+}
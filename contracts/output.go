@@ -0,0 +1,128 @@
+package contracts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OutputStream - STDOUT or STDERR
+type OutputStream int
+
+// OutputStreamStdOut -
+const (
+	OutputStreamStdOut OutputStream = iota // 0 -> STDOUT, also the terminal output in PTY mode
+	OutputStreamStdErr                     // 1 -> STDERR
+)
+
+// String - stringer interface
+func (thisRef OutputStream) String() string {
+	switch thisRef {
+	case OutputStreamStdOut:
+		return "stdout"
+	case OutputStreamStdErr:
+		return "stderr"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - streams are written as `"stdout"` in JSON
+func (thisRef OutputStream) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// OutputLine - a line of output retained by a process
+type OutputLine struct {
+	Sequence  int64        `json:"sequence"` // grows with every line, across restarts
+	Stream    OutputStream `json:"stream"`
+	Time      time.Time    `json:"time"`
+	Data      []byte       `json:"data"`      // a chunk in `OutputModeRaw`
+	Truncated bool         `json:"truncated"` // the line was longer than `MaxLineLength`, the rest was dropped
+}
+
+// OutputBufferSize - how much output a process retains, the first limit reached wins
+type OutputBufferSize struct {
+	Lines int `json:"lines"` // 0 means 1000, negative means none
+	Bytes int `json:"bytes"` // 0 means 1 MiB, negative means none
+}
+
+// OutputBackpressure - what happens when an output subscriber falls behind
+type OutputBackpressure int
+
+// OutputBackpressureBlock -
+const (
+	OutputBackpressureBlock      OutputBackpressure = iota // 0 -> the process output waits for the subscriber
+	OutputBackpressureDropOldest                           // 1 -> the oldest queued line is dropped to make room
+	OutputBackpressureDropNewest                           // 2 -> the new line is dropped
+)
+
+// String - stringer interface
+func (thisRef OutputBackpressure) String() string {
+	switch thisRef {
+	case OutputBackpressureBlock:
+		return "block"
+	case OutputBackpressureDropOldest:
+		return "drop-oldest"
+	case OutputBackpressureDropNewest:
+		return "drop-newest"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - policies are written as `"drop-oldest"` in JSON
+func (thisRef OutputBackpressure) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// OutputSubscription - which output a subscriber gets and how it is queued
+type OutputSubscription struct {
+	Stream       OutputStream       `json:"stream"`
+	Backpressure OutputBackpressure `json:"backpressure"`
+	BufferSize   int                `json:"bufferSize"` // lines queued for the subscriber, 0 means 64
+}
+
+// OutputMode - how output is split before it reaches the readers
+type OutputMode int
+
+// OutputModeLines -
+const (
+	OutputModeLines OutputMode = iota // 0 -> lines without the line break, cut at `MaxLineLength`
+	OutputModeRaw                     // 1 -> chunks exactly as read, for binary output
+)
+
+// String - stringer interface
+func (thisRef OutputMode) String() string {
+	switch thisRef {
+	case OutputModeLines:
+		return "lines"
+	case OutputModeRaw:
+		return "raw"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - modes are written as `"raw"` in JSON
+func (thisRef OutputMode) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// UnmarshalText - allows the mode to be read as `"raw"` from JSON
+func (thisRef *OutputMode) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "", "lines":
+		*thisRef = OutputModeLines
+	case "raw":
+		*thisRef = OutputModeRaw
+
+	default:
+		return fmt.Errorf("unknown output mode [%s]", string(text))
+	}
+
+	return nil
+}
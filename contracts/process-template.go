@@ -1,10 +1,12 @@
 package contracts
 
+import "io"
+
 // ProcessOutputReader -
 type ProcessOutputReader func(params interface{}, outputData []byte)
 
 // ProcessStoppedDelegate -
-type ProcessStoppedDelegate func(params interface{})
+type ProcessStoppedDelegate func(params interface{}, exitStatus ExitStatus)
 
 // ProcessTemplate -
 type ProcessTemplate struct {
@@ -12,4 +14,63 @@ type ProcessTemplate struct {
 	Args             []string `json:"args"`
 	WorkingDirectory string   `json:"workingDirectory"`
 	Environment      []string `json:"environment"`
+
+	User                string   `json:"user"`                // Unix only, name or numeric ID, empty means the current user
+	Group               string   `json:"group"`               // Unix only, name or numeric ID, empty means the primary group of `User`
+	SupplementaryGroups []string `json:"supplementaryGroups"` // names or numeric IDs, empty means the groups `User` is a member of
+	UserEnvironment     bool     `json:"userEnvironment"`     // set HOME, SHELL, USER and LOGNAME of `User`, over `Environment`
+
+	Requires []string `json:"requires"` // tags that must be running before this one starts, it is not started if they fail
+	After    []string `json:"after"`    // tags started before this one if they are started together, failures do not matter
+
+	RestartPolicy RestartPolicy `json:"restartPolicy"`
+	StopStrategy  StopStrategy  `json:"stopStrategy"`
+
+	Readiness HealthCheck `json:"readiness"` // checked until it passes once per run, dependents wait for it
+	Liveness  HealthCheck `json:"liveness"`  // checked while the process runs, after it is ready
+
+	NewProcessGroup bool `json:"newProcessGroup"` // start in its own process group, `Stop` signals the whole group and kills what is left of it
+	NewSession      bool `json:"newSession"`      // start in its own session (and process group), same `Stop` as `NewProcessGroup`
+	KillTree        bool `json:"killTree"`        // after `Stop`, kill the descendants that escaped the group
+
+	ParentDeathSignal string `json:"parentDeathSignal"` // Linux only, like `SIGKILL`, sent to the process if the supervisor dies before it
+
+	ResourceLimits ResourceLimits `json:"resourceLimits"` // Linux only, set before the process runs its first instruction, it fails to start if they can't be set
+	Cgroup         Cgroup         `json:"cgroup"`         // Linux only, cgroup v2 group with memory, CPU, PIDs and IO limits
+	Namespaces     Namespaces     `json:"namespaces"`     // Linux only, PID, mount, network, UTS, IPC and user namespaces, chroot and hostname
+
+	StdinData   TextBytes `json:"stdinData"` // written to STDIN once started, then STDIN is closed unless `StdinOpen`
+	StdinFile   string    `json:"stdinFile"` // same as `StdinData`, from a file
+	StdinReader io.Reader `json:"-"`         // same as `StdinData`, from a reader, read once, restarts only get what is left of it, never closed
+	StdinOpen   bool      `json:"stdinOpen"` // keep STDIN open, write to it through `RuningProcess.Stdin()`
+
+	PTY     bool    `json:"pty"`     // Linux only, run in a pseudo terminal, output comes through `OnStdOut`, input goes through `Stdin()`
+	PTYSize PTYSize `json:"ptySize"` // initial window size, 0 means 24x80
+
+	OutputMode    OutputMode       `json:"outputMode"`    // lines or raw chunks, for readers, subscribers and files
+	MaxLineLength int              `json:"maxLineLength"` // longer lines are cut and reported as truncated, 0 means 64 KiB
+	OutputBuffer  OutputBufferSize `json:"outputBuffer"`  // STDOUT and STDERR retained for `RuningProcess.Output()`
+	StdOutFile    OutputFile       `json:"stdOutFile"`    // STDOUT is appended to it if it has a path, across restarts
+	StdErrFile    OutputFile       `json:"stdErrFile"`    // STDERR is appended to it if it has a path, not used in PTY mode
+	LogParsing    LogParsing       `json:"logParsing"`    // parse JSON or logfmt lines into log records
+}
+
+// PTYSize - window size of a pseudo terminal
+type PTYSize struct {
+	Rows uint16 `json:"rows"`
+	Cols uint16 `json:"cols"`
+}
+
+// TextBytes - `[]byte` written as plain text in JSON and config files, not as base64
+type TextBytes []byte
+
+// MarshalText - allows the bytes to be written as `"hello"` in JSON
+func (thisRef TextBytes) MarshalText() ([]byte, error) {
+	return []byte(thisRef), nil
+}
+
+// UnmarshalText - allows the bytes to be read as `"hello"` from JSON
+func (thisRef *TextBytes) UnmarshalText(text []byte) error {
+	*thisRef = append(TextBytes{}, text...)
+	return nil
 }
@@ -0,0 +1,54 @@
+package contracts
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrReloadFailed -
+var ErrReloadFailed = errors.New("ErrReloadFailed")
+
+// ReloadAction - what a reload does to a tag
+type ReloadAction int
+
+// ReloadRemove -
+const (
+	ReloadRemove  ReloadAction = iota // 0 -> not desired anymore, stopped and removed, kept if it did not stop
+	ReloadRestart                     // 1 -> template changed, stopped and spawned with the new one
+	ReloadAdd                         // 2 -> new, spawned
+	ReloadKeep                        // 3 -> unchanged, left alone
+)
+
+// String - stringer interface
+func (thisRef ReloadAction) String() string {
+	switch thisRef {
+	case ReloadRemove:
+		return "remove"
+	case ReloadRestart:
+		return "restart"
+	case ReloadAdd:
+		return "add"
+	case ReloadKeep:
+		return "keep"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - actions are written as `"restart"` in JSON
+func (thisRef ReloadAction) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// ReloadStep -
+type ReloadStep struct {
+	Tag    string       `json:"tag"`
+	Action ReloadAction `json:"action"`
+	Error  string       `json:"error"` // set if applying the step failed
+}
+
+// ReloadPlan - steps in the order they are applied, removals first, then restarts, then additions
+type ReloadPlan struct {
+	Steps []ReloadStep `json:"steps"`
+}
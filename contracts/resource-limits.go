@@ -0,0 +1,37 @@
+package contracts
+
+import "errors"
+
+// ErrResourceLimitsNotSupported -
+var ErrResourceLimitsNotSupported = errors.New("ErrResourceLimitsNotSupported")
+
+// RLimitInfinity - no limit
+const RLimitInfinity = ^uint64(0)
+
+// ResourceLimit - soft and hard value of one POSIX rlimit
+type ResourceLimit struct {
+	Soft uint64 `json:"soft"` // what is enforced, the process can raise it up to `Hard`
+	Hard uint64 `json:"hard"`
+}
+
+// ResourceLimits - POSIX rlimits, `nil` keeps the inherited value
+//
+// Go can't run code between `fork()` and `exec()`, the process is traced until it stops after `exec()` and the
+// limits are set with `prlimit()` before it goes on: the supervisor must be allowed to trace its children, and
+// setuid executables don't get their privileges unless the supervisor runs as root
+type ResourceLimits struct {
+	OpenFiles    *ResourceLimit `json:"openFiles"`    // RLIMIT_NOFILE
+	CoreSize     *ResourceLimit `json:"coreSize"`     // RLIMIT_CORE, bytes
+	AddressSpace *ResourceLimit `json:"addressSpace"` // RLIMIT_AS, bytes
+	CPUTime      *ResourceLimit `json:"cpuTime"`      // RLIMIT_CPU, seconds
+	Processes    *ResourceLimit `json:"processes"`    // RLIMIT_NPROC, counted for the user
+}
+
+// IsEmpty - `true` if no limit is set
+func (thisRef ResourceLimits) IsEmpty() bool {
+	return thisRef.OpenFiles == nil &&
+		thisRef.CoreSize == nil &&
+		thisRef.AddressSpace == nil &&
+		thisRef.CPUTime == nil &&
+		thisRef.Processes == nil
+}
@@ -0,0 +1,86 @@
+package contracts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RestartMode - when should the monitor bring a process back after it exits
+type RestartMode int
+
+// RestartNever -
+const (
+	RestartNever         RestartMode = iota // 0 -> never restart, this is the default
+	RestartAlways                           // 1 -> restart after any exit, a `Monitor.Stop` pauses this until the next `Monitor.Start`
+	RestartOnFailure                        // 2 -> restart only if the exit code is not 0 or the process was killed by a signal
+	RestartUnlessStopped                    // 3 -> like `RestartAlways`, but once stopped through `Monitor.Stop` it stays stopped across `StartAll`, `Reload` and re-spawns until the next `Monitor.Start`
+)
+
+// String - stringer interface
+func (thisRef RestartMode) String() string {
+	switch thisRef {
+	case RestartNever:
+		return "never"
+	case RestartAlways:
+		return "always"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartUnlessStopped:
+		return "unless-stopped"
+
+	default:
+		return fmt.Sprintf("%d", int(thisRef))
+	}
+}
+
+// MarshalText - allows the mode to be written as `"on-failure"` in JSON
+func (thisRef RestartMode) MarshalText() ([]byte, error) {
+	return []byte(thisRef.String()), nil
+}
+
+// UnmarshalText - allows the mode to be read as `"on-failure"` from JSON
+func (thisRef *RestartMode) UnmarshalText(text []byte) error {
+	switch strings.ToLower(strings.TrimSpace(string(text))) {
+	case "", "never", "no":
+		*thisRef = RestartNever
+	case "always":
+		*thisRef = RestartAlways
+	case "on-failure":
+		*thisRef = RestartOnFailure
+	case "unless-stopped":
+		*thisRef = RestartUnlessStopped
+
+	default:
+		return fmt.Errorf("unknown restart mode [%s]", string(text))
+	}
+
+	return nil
+}
+
+// RestartPolicy - describes how the monitor restarts a process that exited
+type RestartPolicy struct {
+	Mode        RestartMode   `json:"mode"`
+	MaxRestarts int           `json:"maxRestarts"` // 0 means unlimited
+	Window      time.Duration `json:"window"`      // sliding window for `MaxRestarts`, 0 means the lifetime of the tag
+	Backoff     BackoffPolicy `json:"backoff"`
+}
+
+// BackoffPolicy - delays between consecutive restarts, `InitialDelay` 0 restarts right away
+type BackoffPolicy struct {
+	InitialDelay time.Duration `json:"initialDelay"`
+	Multiplier   float64       `json:"multiplier"` // grows the delay after each restart, 0 means 2
+	MaxDelay     time.Duration `json:"maxDelay"`   // 0 means no cap
+	Jitter       float64       `json:"jitter"`     // 0..1, fraction of the delay randomly added or removed
+	ResetAfter   time.Duration `json:"resetAfter"` // a run lasting this long starts the schedule over, 0 never resets
+}
+
+// RestartState - restart bookkeeping of a tag as seen by the monitor
+type RestartState struct {
+	Restarts       int           `json:"restarts"`       // restarts inside the sliding window
+	BackoffAttempt int           `json:"backoffAttempt"` // restarts since the process was last stable
+	CurrentDelay   time.Duration `json:"currentDelay"`   // delay used for the last scheduled restart
+	Pending        bool          `json:"pending"`        // a restart is scheduled at `NextRestartAt`
+	NextRestartAt  time.Time     `json:"nextRestartAt"`
+	GaveUp         bool          `json:"gaveUp"` // `MaxRestarts` was reached
+}
@@ -1,13 +1,24 @@
 package contracts
 
 import (
+	"context"
 	"errors"
+	"io"
 	"time"
 )
 
 // ErrProcessDoesNotExist -
 var ErrProcessDoesNotExist = errors.New("ErrProcessDoesNotExist")
 
+// ErrProcessNotStarted -
+var ErrProcessNotStarted = errors.New("ErrProcessNotStarted")
+
+// ErrStdinNotAvailable -
+var ErrStdinNotAvailable = errors.New("ErrStdinNotAvailable")
+
+// ErrPTYNotAvailable -
+var ErrPTYNotAvailable = errors.New("ErrPTYNotAvailable")
+
 // ProcessState -
 type ProcessState int
 
@@ -63,23 +74,48 @@ type RuntimeProcess struct {
 	GroupID          int          `json:"groupID"`
 	State            ProcessState `json:"state"`
 
+	Limits ResourceLimits `json:"limits"` // Linux only, the limits in effect, only filled by `Details()`
+
 	// FIXME
 	sessionID       int `json:"-"`
 	effectiveUserID int `json:"-"`
 }
 
+// ExitStatus - how a process ended
+type ExitStatus struct {
+	Code      int       `json:"code"`      // -1 if killed by a signal or if not known
+	Signal    int       `json:"signal"`    // signal that killed the process, 0 if none
+	Known     bool      `json:"known"`     // false for processes not started by `Start`, the OS tells only whoever started them
+	OOMKilled bool      `json:"oomKilled"` // the OOM killer killed something in the cgroup of the process during the run
+	ExitedAt  time.Time `json:"exitedAt"`
+}
+
 // RuningProcess - represents a running process
 type RuningProcess interface {
 	Start() error
+	StartContext(ctx context.Context) error
 	Stop(tag string, attempts int, waitTimeout time.Duration) error
+	StopContext(ctx context.Context) error
+	Signal(signalName string) error
+	Wait(ctx context.Context) (ExitStatus, error)
 	IsRunning() bool
 	Details() RuntimeProcess
+	Stats() (ProcessStats, error)
+	CgroupStats() (CgroupStats, error)
+	Stdin() io.WriteCloser
+	ResizePTY(size PTYSize) error
 
 	ExitCode() int
 	StartedAt() time.Time
 	StoppedAt() time.Time
 
+	Output(tail int) []OutputLine
+	FollowOutput(ctx context.Context, tail int) <-chan OutputLine
+	SubscribeOutput(subscription OutputSubscription) (<-chan OutputLine, func())
+
 	OnStdOut(outputReader ProcessOutputReader, params interface{})
 	OnStdErr(outputReader ProcessOutputReader, params interface{})
 	OnStop(stoppedDelegate ProcessStoppedDelegate, params interface{})
+
+	Close() error
 }
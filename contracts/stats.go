@@ -0,0 +1,26 @@
+package contracts
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrStatsNotSupported -
+var ErrStatsNotSupported = errors.New("ErrStatsNotSupported")
+
+// ProcessStats - resource usage of a process at `Time`
+type ProcessStats struct {
+	Time                       time.Time     `json:"time"`
+	ProcessID                  int           `json:"processID"`
+	CPUUser                    time.Duration `json:"cpuUser"`
+	CPUSystem                  time.Duration `json:"cpuSystem"`
+	CPUPercent                 float64       `json:"cpuPercent"` // since the previous sample, or since start for the first one, 100 is one core
+	MemoryRSS                  uint64        `json:"memoryRSS"`  // bytes
+	MemoryVSZ                  uint64        `json:"memoryVSZ"`  // bytes
+	Threads                    int           `json:"threads"`
+	OpenFDs                    int           `json:"openFDs"`
+	ReadBytes                  uint64        `json:"readBytes"`  // from storage, 0 if the OS does not tell
+	WriteBytes                 uint64        `json:"writeBytes"` // to storage, 0 if the OS does not tell
+	VoluntaryContextSwitches   uint64        `json:"voluntaryContextSwitches"`
+	InvoluntaryContextSwitches uint64        `json:"involuntaryContextSwitches"`
+}
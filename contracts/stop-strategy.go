@@ -0,0 +1,21 @@
+package contracts
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrStopFailed -
+var ErrStopFailed = errors.New("ErrStopFailed")
+
+// StopStep - one step of a stop strategy, sends `Signal` or runs `Command`
+type StopStep struct {
+	Signal      string        `json:"signal"`      // like `SIGQUIT`, `SIGINT` is sent as CTRL+C on Windows and `SIGKILL` kills on all
+	Command     []string      `json:"command"`     // executable and args, `$MAINPID` is replaced with the PID and also set as env
+	GracePeriod time.Duration `json:"gracePeriod"` // wait this long for the exit before the next step, 0 uses the stop wait timeout
+}
+
+// StopStrategy - ordered steps followed by `Stop()`, no steps means SIGINT -> SIGTERM -> SIGKILL -> kill
+type StopStrategy struct {
+	Steps []StopStep `json:"steps"`
+}
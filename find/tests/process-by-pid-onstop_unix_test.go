@@ -0,0 +1,76 @@
+// +build !windows
+
+package tests
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"github.com/codemodify/systemkit-processes/find"
+)
+
+func TestProcessByPIDOnStop(t *testing.T) {
+	// `sleep` gets orphaned, it is not our child
+	output, err := exec.Command("sh", "-c", "sleep 1 > /dev/null & echo $!").Output()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp, err := find.ProcessByPID(pid)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	stopped := make(chan contracts.ExitStatus, 1)
+	rp.OnStop(func(params interface{}, exitStatus contracts.ExitStatus) {
+		stopped <- exitStatus
+	}, nil)
+
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		t.Fatal("OnStop was not called")
+	}
+
+	if rp.IsRunning() {
+		t.Fatal("should not be running")
+	}
+}
+
+func TestProcessByPIDOnStopOwnChild(t *testing.T) {
+	// our child, started elsewhere, the exit status stays with `cmd`
+	cmd := exec.Command("sh", "-c", "sleep 0.5; exit 7")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp, err := find.ProcessByPID(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	stopped := make(chan contracts.ExitStatus, 1)
+	rp.OnStop(func(params interface{}, exitStatus contracts.ExitStatus) {
+		stopped <- exitStatus
+	}, nil)
+
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		t.Fatal("OnStop was not called")
+	}
+
+	cmd.Wait()
+	if exitCode := cmd.ProcessState.ExitCode(); exitCode != 7 {
+		t.Fatalf("expected exit code 7 for the owner, got %d", exitCode)
+	}
+}
@@ -4,5 +4,7 @@ go 1.15
 
 require (
 	github.com/codemodify/systemkit-logging v1.9.3
+	github.com/pelletier/go-toml v1.9.5
 	golang.org/x/sys v0.0.0-20210123231150-1d476976d117
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,318 @@
+package internal
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// rotatedFileTimeFormat - suffix of rotated files, sorts in the order they were rotated
+const rotatedFileTimeFormat = "20060102-150405.000000000"
+
+// outputFile - appends lines to `Path`, rotates it to `Path.<time>[.gz]`
+type outputFile struct {
+	config   contracts.OutputFile
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	fileSync *sync.Mutex
+
+	rotatedSync *sync.Mutex     // one compression and retention at a time
+	rotations   *sync.WaitGroup // pending ones, `Close` waits for them
+}
+
+// NewOutputFile - opens or creates the file, new lines are appended to what is there
+func NewOutputFile(config contracts.OutputFile) (contracts.OutputSink, error) {
+	if len(strings.TrimSpace(config.Path)) == 0 {
+		return nil, fmt.Errorf("%s: output-file-FAIL, no path", logID)
+	}
+
+	thisRef := &outputFile{
+		config:      config,
+		fileSync:    &sync.Mutex{},
+		rotatedSync: &sync.Mutex{},
+		rotations:   &sync.WaitGroup{},
+	}
+
+	if err := thisRef.open(); err != nil {
+		return nil, err
+	}
+
+	return thisRef, nil
+}
+
+// OnOutput - a `contracts.ProcessOutputReader`, failures are logged
+func (thisRef *outputFile) OnOutput(params interface{}, outputData []byte) {
+	if thisRef.config.Raw {
+		if err := thisRef.write(outputData); err != nil {
+			logging.Warningf("%s: output-file-WRITE-FAIL [%s], [%s]", logID, thisRef.config.Path, err.Error())
+		}
+		return
+	}
+
+	line := make([]byte, 0, len(outputData)+len(thisRef.config.TimestampFormat)+2)
+	if len(thisRef.config.TimestampFormat) > 0 {
+		line = append(line, time.Now().Format(thisRef.config.TimestampFormat)...)
+		line = append(line, ' ')
+	}
+	line = append(line, outputData...)
+	line = append(line, '\n')
+
+	if err := thisRef.write(line); err != nil {
+		logging.Warningf("%s: output-file-WRITE-FAIL [%s], [%s]", logID, thisRef.config.Path, err.Error())
+	}
+}
+
+// Close - waits for the rotated files still being compressed
+func (thisRef *outputFile) Close() error {
+	thisRef.fileSync.Lock()
+	defer thisRef.fileSync.Unlock()
+
+	if thisRef.file == nil {
+		return nil
+	}
+
+	err := thisRef.file.Close()
+	thisRef.file = nil
+
+	thisRef.rotations.Wait()
+
+	return err
+}
+
+func (thisRef *outputFile) write(line []byte) error {
+	thisRef.fileSync.Lock()
+	defer thisRef.fileSync.Unlock()
+
+	if thisRef.file == nil {
+		return os.ErrClosed
+	}
+
+	if thisRef.needsRotation(int64(len(line))) {
+		if err := thisRef.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := thisRef.file.Write(line)
+	thisRef.size += int64(n)
+
+	return err
+}
+
+func (thisRef *outputFile) needsRotation(extra int64) bool {
+	if thisRef.size == 0 {
+		return false
+	}
+
+	if thisRef.config.MaxSize > 0 && thisRef.size+extra > thisRef.config.MaxSize {
+		return true
+	}
+
+	return thisRef.config.MaxAge > 0 && time.Since(thisRef.openedAt) >= thisRef.config.MaxAge
+}
+
+func (thisRef *outputFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(thisRef.config.Path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(thisRef.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	thisRef.file = file
+	thisRef.size = info.Size()
+	thisRef.openedAt = time.Now()
+
+	return nil
+}
+
+// rotate - call with `fileSync` held, only the rename is done here, compression and retention don't hold up the writes
+func (thisRef *outputFile) rotate() error {
+	if err := thisRef.file.Close(); err != nil {
+		logging.Warningf("%s: output-file-CLOSE-FAIL [%s], [%s]", logID, thisRef.config.Path, err.Error())
+	}
+	thisRef.file = nil
+
+	rotatedPath := thisRef.config.Path + "." + time.Now().Format(rotatedFileTimeFormat)
+	if err := os.Rename(thisRef.config.Path, rotatedPath); err != nil {
+		logging.Warningf("%s: output-file-ROTATE-FAIL [%s], [%s]", logID, thisRef.config.Path, err.Error())
+		rotatedPath = ""
+	}
+
+	thisRef.rotations.Add(1)
+	go thisRef.afterRotate(rotatedPath)
+
+	return thisRef.open()
+}
+
+// afterRotate - compresses `rotatedPath` if set and applies the retention, one rotation at a time
+func (thisRef *outputFile) afterRotate(rotatedPath string) {
+	defer thisRef.rotations.Done()
+
+	thisRef.rotatedSync.Lock()
+	defer thisRef.rotatedSync.Unlock()
+
+	// the retention of a later rotation may have removed it already
+	if len(rotatedPath) > 0 && thisRef.config.Compress {
+		if err := compressFile(rotatedPath); err != nil && !os.IsNotExist(err) {
+			logging.Warningf("%s: output-file-COMPRESS-FAIL [%s], [%s]", logID, rotatedPath, err.Error())
+		}
+	}
+
+	thisRef.removeOldFiles()
+}
+
+// removeOldFiles - keeps the newest `MaxFiles` rotated files
+func (thisRef *outputFile) removeOldFiles() {
+	if thisRef.config.MaxFiles <= 0 {
+		return
+	}
+
+	candidates, err := filepath.Glob(thisRef.config.Path + ".*")
+	if err != nil {
+		return
+	}
+
+	rotated := []string{}
+	for _, candidate := range candidates {
+		if isRotatedFile(thisRef.config.Path, candidate) {
+			rotated = append(rotated, candidate)
+		}
+	}
+
+	sort.Strings(rotated)
+	for len(rotated) > thisRef.config.MaxFiles {
+		if err := os.Remove(rotated[0]); err != nil {
+			logging.Warningf("%s: output-file-REMOVE-FAIL [%s], [%s]", logID, rotated[0], err.Error())
+		}
+		rotated = rotated[1:]
+	}
+}
+
+// isRotatedFile - `path.<time>` or `path.<time>.gz`, not other files starting with `path` nor compressions in progress
+func isRotatedFile(path string, candidate string) bool {
+	suffix := strings.TrimSuffix(strings.TrimPrefix(candidate, path+"."), ".gz")
+	if len(suffix) != len(rotatedFileTimeFormat) {
+		return false
+	}
+
+	_, err := time.Parse(rotatedFileTimeFormat, suffix)
+
+	return err == nil
+}
+
+// compressFile - replaces `path` with `path.gz`
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".gz-")
+	if err != nil {
+		return err
+	}
+
+	writer := gzip.NewWriter(destination)
+	_, err = io.Copy(writer, source)
+	if err == nil {
+		err = writer.Close()
+	}
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(destination.Name(), path+".gz")
+	}
+	if err != nil {
+		os.Remove(destination.Name())
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// openOutputFiles - opened by the first `Start` and kept open until `Close`, restarts append to the same files
+func (thisRef *runingProcess) openOutputFiles() error {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
+	var err error
+
+	raw := (thisRef.processTemplate.OutputMode == contracts.OutputModeRaw)
+
+	if thisRef.stdOutFile == nil && len(thisRef.processTemplate.StdOutFile.Path) > 0 {
+		config := thisRef.processTemplate.StdOutFile
+		config.Raw = config.Raw || raw
+		thisRef.stdOutFile, err = NewOutputFile(config)
+		if err != nil {
+			return err
+		}
+	}
+
+	if thisRef.stdErrFile == nil && len(thisRef.processTemplate.StdErrFile.Path) > 0 && !thisRef.processTemplate.PTY {
+		config := thisRef.processTemplate.StdErrFile
+		config.Raw = config.Raw || raw
+		thisRef.stdErrFile, err = NewOutputFile(config)
+		if err != nil {
+			if thisRef.stdOutFile != nil {
+				thisRef.stdOutFile.Close()
+				thisRef.stdOutFile = nil
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close - closes the output files once the current run exited and its output was written, call when the process is no longer used
+func (thisRef *runingProcess) Close() error {
+	thisRef.OnStop(func(params interface{}, exitStatus contracts.ExitStatus) {
+		go func() {
+			thisRef.pumps.Wait()
+			thisRef.closeOutputFiles()
+		}()
+	}, nil)
+
+	return nil
+}
+
+func (thisRef *runingProcess) closeOutputFiles() {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
+	for _, sink := range []contracts.OutputSink{thisRef.stdOutFile, thisRef.stdErrFile} {
+		if sink == nil {
+			continue
+		}
+
+		if err := sink.Close(); err != nil {
+			logging.Warningf("%s: output-file-CLOSE-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
+		}
+	}
+
+	thisRef.stdOutFile = nil
+	thisRef.stdErrFile = nil
+}
@@ -0,0 +1,13 @@
+// +build linux,!go1.20
+
+package internal
+
+import (
+	"os"
+	"os/exec"
+)
+
+// cloneIntoCgroup - Go can't clone into a cgroup before 1.20, the PID is written to `cgroup.procs` once started
+func cloneIntoCgroup(osCmd *exec.Cmd, dir *os.File) bool {
+	return false
+}
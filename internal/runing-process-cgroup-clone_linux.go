@@ -0,0 +1,16 @@
+// +build linux,go1.20
+
+package internal
+
+import (
+	"os"
+	"os/exec"
+)
+
+// cloneIntoCgroup - `CLONE_INTO_CGROUP`, needs Go 1.20+ and Linux 5.7+
+func cloneIntoCgroup(osCmd *exec.Cmd, dir *os.File) bool {
+	osCmd.SysProcAttr.UseCgroupFD = true
+	osCmd.SysProcAttr.CgroupFD = int(dir.Fd())
+
+	return true
+}
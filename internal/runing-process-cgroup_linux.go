@@ -0,0 +1,245 @@
+// +build linux
+
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+	"golang.org/x/sys/unix"
+)
+
+// cgroupDrainTimeout - how long the removal waits for the killed members of a group to go away
+const cgroupDrainTimeout = 2 * time.Second
+
+// CgroupFakeFS - tests only, a plain folder is accepted as `Parent` and stands in for cgroupfs
+var CgroupFakeFS = false
+
+// runCgroup - the cgroup v2 group of one run
+type runCgroup struct {
+	path       string
+	isCgroupFS bool     // `false` for the fake cgroupfs of tests, see `CgroupFakeFS`
+	dir        *os.File // `CLONE_INTO_CGROUP` target, closed once started
+	cloned     bool     // `true` if the process was cloned into the group, the PID is written to `cgroup.procs` otherwise
+	oomKills   uint64   // `oom_kill` when the run started
+	created    []string // fake cgroupfs only, the files written by this code, removed with the group
+}
+
+// createCgroup - the group with its limits, `name` is used if the config has none
+func createCgroup(config contracts.Cgroup, name string) (*runCgroup, error) {
+	if len(config.Name) > 0 {
+		name = config.Name
+	}
+	name = strings.ReplaceAll(name, string(filepath.Separator), "-")
+
+	statfs := unix.Statfs_t{}
+	if err := unix.Statfs(config.Parent, &statfs); err != nil {
+		return nil, fmt.Errorf("%w: %s", contracts.ErrCgroupNotAvailable, err.Error())
+	}
+
+	group := &runCgroup{
+		path:       filepath.Join(config.Parent, name),
+		isCgroupFS: statfs.Type == unix.CGROUP2_SUPER_MAGIC,
+		created:    []string{},
+	}
+
+	// limits written to a plain folder, or to a cgroup v1 hierarchy, are not enforced
+	if !group.isCgroupFS && !CgroupFakeFS {
+		return nil, fmt.Errorf("%w: %s is not a cgroup v2 folder", contracts.ErrCgroupNotAvailable, config.Parent)
+	}
+
+	limits := map[string]string{}
+	controllers := []string{}
+	if config.MemoryMax > 0 {
+		limits["memory.max"] = strconv.FormatInt(config.MemoryMax, 10)
+		controllers = append(controllers, "+memory")
+	}
+	if config.CPUMax > 0 {
+		const period = 100000 // microseconds, the kernel default
+		limits["cpu.max"] = fmt.Sprintf("%d %d", int64(config.CPUMax*period), period)
+		controllers = append(controllers, "+cpu")
+	}
+	if config.PidsMax > 0 {
+		limits["pids.max"] = strconv.FormatInt(config.PidsMax, 10)
+		controllers = append(controllers, "+pids")
+	}
+	if config.IOWeight > 0 {
+		limits["io.weight"] = fmt.Sprintf("default %d", config.IOWeight)
+		controllers = append(controllers, "+io")
+	}
+
+	// the parent hands the controllers down, it fails if the parent has processes of its own
+	if len(controllers) > 0 {
+		subtreeControl := filepath.Join(config.Parent, "cgroup.subtree_control")
+		if err := ioutil.WriteFile(subtreeControl, []byte(strings.Join(controllers, " ")), 0644); err != nil {
+			return nil, fmt.Errorf("%w: %s", contracts.ErrCgroupNotAvailable, err.Error())
+		}
+	}
+
+	// never adopted, the group is removed with what is in it
+	if err := os.Mkdir(group.path, 0755); err != nil {
+		return nil, fmt.Errorf("%w: %s", contracts.ErrCgroupNotAvailable, err.Error())
+	}
+
+	for _, file := range []string{"memory.max", "cpu.max", "pids.max", "io.weight"} {
+		if value, ok := limits[file]; ok {
+			if err := group.writeFile(file, value); err != nil {
+				group.remove()
+				return nil, fmt.Errorf("%w: %s, %s", contracts.ErrCgroupNotAvailable, file, err.Error())
+			}
+		}
+	}
+
+	if group.isCgroupFS {
+		dir, err := os.Open(group.path)
+		if err != nil {
+			group.remove()
+			return nil, fmt.Errorf("%w: %s", contracts.ErrCgroupNotAvailable, err.Error())
+		}
+		group.dir = dir
+	}
+
+	group.oomKills = group.readKeyedValues("memory.events")["oom_kill"]
+
+	return group, nil
+}
+
+// attach - the process is cloned straight into the group where Go supports it, it never runs outside of it
+func (thisRef *runCgroup) attach(osCmd *exec.Cmd) {
+	if thisRef.dir != nil {
+		thisRef.cloned = cloneIntoCgroup(osCmd, thisRef.dir)
+	}
+}
+
+// started - call once the process started, or failed to
+func (thisRef *runCgroup) started(pid int) error {
+	if thisRef.dir != nil {
+		thisRef.dir.Close()
+		thisRef.dir = nil
+	}
+
+	if thisRef.cloned || pid <= 0 {
+		return nil
+	}
+
+	return thisRef.writeFile("cgroup.procs", strconv.Itoa(pid))
+}
+
+// writeFile - a file of the group, remembered on the fake cgroupfs to be removed with it
+func (thisRef *runCgroup) writeFile(file string, value string) error {
+	path := filepath.Join(thisRef.path, file)
+	if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
+		return err
+	}
+
+	if !thisRef.isCgroupFS {
+		thisRef.created = append(thisRef.created, path)
+	}
+
+	return nil
+}
+
+// finish - call once the process exited, tells if the OOM killer hit the group then removes it
+func (thisRef *runCgroup) finish(exitStatus contracts.ExitStatus) contracts.ExitStatus {
+	if oomKills := thisRef.readKeyedValues("memory.events")["oom_kill"]; oomKills > thisRef.oomKills {
+		exitStatus.OOMKilled = true
+	}
+
+	thisRef.remove()
+
+	return exitStatus
+}
+
+// remove - kills what is left in the group, then removes it
+func (thisRef *runCgroup) remove() {
+	if thisRef.dir != nil {
+		thisRef.dir.Close()
+		thisRef.dir = nil
+	}
+
+	// only what this code wrote, the folder stays if something else is in it
+	if !thisRef.isCgroupFS {
+		for _, path := range thisRef.created {
+			os.Remove(path)
+		}
+		os.Remove(thisRef.path)
+		return
+	}
+
+	// `cgroup.kill` needs Linux 5.14+, the members are signaled one by one otherwise
+	if err := ioutil.WriteFile(filepath.Join(thisRef.path, "cgroup.kill"), []byte("1"), 0644); err != nil {
+		for _, pid := range thisRef.members() {
+			unix.Kill(pid, unix.SIGKILL)
+		}
+	}
+
+	deadline := time.Now().Add(cgroupDrainTimeout)
+	for len(thisRef.members()) > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := unix.Rmdir(thisRef.path); err != nil && !os.IsNotExist(err) {
+		logging.Warningf("%s: cgroup-remove-FAIL [%s], [%s]", logID, thisRef.path, err.Error())
+	}
+}
+
+func (thisRef *runCgroup) members() []int {
+	data, _ := ioutil.ReadFile(filepath.Join(thisRef.path, "cgroup.procs"))
+
+	pids := []int{}
+	for _, field := range strings.Fields(string(data)) {
+		if pid, err := strconv.Atoi(field); err == nil {
+			pids = append(pids, pid)
+		}
+	}
+
+	return pids
+}
+
+func (thisRef *runCgroup) stats() contracts.CgroupStats {
+	cpuStat := thisRef.readKeyedValues("cpu.stat")
+	memoryEvents := thisRef.readKeyedValues("memory.events")
+
+	return contracts.CgroupStats{
+		Path:          thisRef.path,
+		MemoryCurrent: thisRef.readValue("memory.current"),
+		MemoryPeak:    thisRef.readValue("memory.peak"),
+		CPUUsageUsec:  cpuStat["usage_usec"],
+		CPUUserUsec:   cpuStat["user_usec"],
+		CPUSystemUsec: cpuStat["system_usec"],
+		PidsCurrent:   thisRef.readValue("pids.current"),
+		OOMEvents:     memoryEvents["oom"],
+		OOMKillEvents: memoryEvents["oom_kill"],
+	}
+}
+
+// readValue - single number files like `memory.current`, 0 if missing
+func (thisRef *runCgroup) readValue(file string) uint64 {
+	data, _ := ioutil.ReadFile(filepath.Join(thisRef.path, file))
+	value, _ := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+
+	return value
+}
+
+// readKeyedValues - `key value` lines like `memory.events`, empty if missing
+func (thisRef *runCgroup) readKeyedValues(file string) map[string]uint64 {
+	values := map[string]uint64{}
+
+	data, _ := ioutil.ReadFile(filepath.Join(thisRef.path, file))
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			values[fields[0]], _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+
+	return values
+}
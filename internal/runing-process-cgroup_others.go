@@ -0,0 +1,32 @@
+// +build !linux
+
+package internal
+
+import (
+	"os/exec"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// runCgroup - cgroups are Linux only
+type runCgroup struct{}
+
+func createCgroup(config contracts.Cgroup, name string) (*runCgroup, error) {
+	return nil, contracts.ErrCgroupNotAvailable
+}
+
+func (thisRef *runCgroup) attach(osCmd *exec.Cmd) {}
+
+func (thisRef *runCgroup) started(pid int) error {
+	return nil
+}
+
+func (thisRef *runCgroup) finish(exitStatus contracts.ExitStatus) contracts.ExitStatus {
+	return exitStatus
+}
+
+func (thisRef *runCgroup) remove() {}
+
+func (thisRef *runCgroup) stats() contracts.CgroupStats {
+	return contracts.CgroupStats{}
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// reapedWaitTimeout - how long a child that lost its exit to the reaper waits for it to be routed
+const reapedWaitTimeout = 5 * time.Second
+
+// children started through this package, their exit status belongs to whoever waits for them, not to the orphan reaper,
+// if the reaper gets it anyway it is routed through the channel
+var (
+	children     = map[int]chan contracts.ExitStatus{}
+	childrenSync = &sync.Mutex{}
+)
+
+// startChild - `start` starts `osCmd`, the PID is tracked before the reaper can look at it
+func startChild(osCmd *exec.Cmd, start func() error) error {
+	childrenSync.Lock()
+	defer childrenSync.Unlock()
+
+	if err := start(); err != nil {
+		return err
+	}
+
+	children[osCmd.Process.Pid] = make(chan contracts.ExitStatus, 1)
+
+	return nil
+}
+
+// untrackChild - once the child was waited for
+func untrackChild(pid int) {
+	childrenSync.Lock()
+	defer childrenSync.Unlock()
+
+	delete(children, pid)
+}
+
+// routeExit - called by the reaper, `false` if the child is not tracked
+func routeExit(pid int, exitStatus contracts.ExitStatus) bool {
+	childrenSync.Lock()
+	defer childrenSync.Unlock()
+
+	exited, ok := children[pid]
+	if !ok {
+		return false
+	}
+
+	select {
+	case exited <- exitStatus:
+	default:
+	}
+
+	return true
+}
+
+// reapedExitStatus - for a tracked child whose wait failed with ECHILD, the exit status the reaper got instead
+func reapedExitStatus(pid int, waitErr error) (contracts.ExitStatus, bool) {
+	if !errors.Is(waitErr, syscall.ECHILD) {
+		return contracts.ExitStatus{}, false
+	}
+
+	childrenSync.Lock()
+	exited, ok := children[pid]
+	childrenSync.Unlock()
+
+	if !ok {
+		return contracts.ExitStatus{}, false
+	}
+
+	select {
+	case exitStatus := <-exited:
+		return exitStatus, true
+	case <-time.After(reapedWaitTimeout):
+		logging.Warningf("%s: reaped-exit-MISSING for [%d]", logID, pid)
+		return contracts.ExitStatus{}, false
+	}
+}
+
+// CombinedOutput - `cmd.CombinedOutput()`, the orphan reaper leaves the command alone
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	if err := startChild(cmd, cmd.Start); err != nil {
+		return nil, err
+	}
+
+	err := cmd.Wait()
+	if exitStatus, ok := reapedExitStatus(cmd.Process.Pid, err); ok {
+		err = exitStatusAsError(exitStatus)
+	}
+	untrackChild(cmd.Process.Pid)
+
+	return output.Bytes(), err
+}
+
+// exitStatusAsError - `nil` for a clean exit, worded like `exec.ExitError`
+func exitStatusAsError(exitStatus contracts.ExitStatus) error {
+	switch {
+	case exitStatus.Signal != 0:
+		return fmt.Errorf("signal: %s", syscall.Signal(exitStatus.Signal).String())
+	case exitStatus.Code != 0:
+		return fmt.Errorf("exit status %d", exitStatus.Code)
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"context"
+	"syscall"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// used by `StopContext()` and by the context cancellation in `StartContext()`
+const (
+	defaultStopAttempts    = 1
+	defaultStopWaitTimeout = 1 * time.Second
+)
+
+// StartContext - starts the process, cancelling `ctx` stops it
+func (thisRef *runingProcess) StartContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := thisRef.Start(); err != nil {
+		return err
+	}
+
+	run := thisRef.currentRun()
+
+	go func() {
+		select {
+		case <-run.exited:
+		case <-ctx.Done():
+			if thisRef.currentRun() != run {
+				return
+			}
+
+			logging.Debugf("%s: context-DONE for [%s], [%s]", logID, thisRef.processTemplate.Executable, ctx.Err().Error())
+			thisRef.Stop("", defaultStopAttempts, defaultStopWaitTimeout)
+		}
+	}()
+
+	return nil
+}
+
+// StopContext - stops the process gracefully, kills it if `ctx` is done before it exits, with its group and tree
+// like `Stop` does
+func (thisRef *runingProcess) StopContext(ctx context.Context) error {
+	run := thisRef.currentRun()
+	if run == nil {
+		return nil
+	}
+
+	osProc := thisRef.osProcess()
+	if osProc == nil {
+		return nil
+	}
+
+	// collect before stopping, like `Stop` does
+	descendants := []int{}
+	if thisRef.processTemplate.KillTree {
+		descendants = descendantsOf(osProc.Pid)
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- thisRef.Stop("", defaultStopAttempts, defaultStopWaitTimeout)
+	}()
+
+	select {
+	case err := <-stopped:
+		return err
+	case <-ctx.Done():
+		logging.Debugf("%s: stop-context-DONE for [%s], kill", logID, thisRef.processTemplate.Executable)
+		signalProcess(osProc, syscall.SIGKILL, thisRef.signalsGroup())
+		killProcesses(descendants)
+		return ctx.Err()
+	}
+}
+
+// Wait - blocks until the process exits or `ctx` is done
+func (thisRef *runingProcess) Wait(ctx context.Context) (contracts.ExitStatus, error) {
+	run := thisRef.currentRun()
+	if run == nil {
+		return contracts.ExitStatus{}, contracts.ErrProcessNotStarted
+	}
+
+	select {
+	case <-run.exited:
+		thisRef.runSync.Lock()
+		defer thisRef.runSync.Unlock()
+
+		return run.exitStatus, nil
+	case <-ctx.Done():
+		return contracts.ExitStatus{}, ctx.Err()
+	}
+}
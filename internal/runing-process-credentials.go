@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// processCredentials - who the process runs as, resolved from the template
+type processCredentials struct {
+	user        *user.User
+	uid         uint32
+	gid         uint32
+	groups      []uint32
+	noSetGroups bool // only root can set groups, others keep theirs and can still run as themselves
+	switchUser  bool // `false` if only the user environment is asked for
+}
+
+// resolveCredentials - `nil` if the template does not ask for a user, a group or the user environment
+func resolveCredentials(processTemplate contracts.ProcessTemplate) (*processCredentials, error) {
+	if len(processTemplate.User) == 0 &&
+		len(processTemplate.Group) == 0 &&
+		len(processTemplate.SupplementaryGroups) == 0 &&
+		!processTemplate.UserEnvironment {
+		return nil, nil
+	}
+
+	u, err := lookupUser(processTemplate.User)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := &processCredentials{
+		user:        u,
+		groups:      []uint32{},
+		noSetGroups: len(processTemplate.SupplementaryGroups) == 0 && os.Geteuid() != 0,
+		switchUser:  len(processTemplate.User) > 0 || len(processTemplate.Group) > 0 || len(processTemplate.SupplementaryGroups) > 0,
+	}
+
+	if credentials.uid, err = parseID(u.Uid); err != nil {
+		return nil, fmt.Errorf("%w: [%s], %s", contracts.ErrUnknownUser, processTemplate.User, err.Error())
+	}
+
+	gid := u.Gid
+	if len(processTemplate.Group) > 0 {
+		if gid, err = lookupGroupID(processTemplate.Group); err != nil {
+			return nil, err
+		}
+	}
+	if credentials.gid, err = parseID(gid); err != nil {
+		return nil, fmt.Errorf("%w: [%s], %s", contracts.ErrUnknownGroup, gid, err.Error())
+	}
+
+	groupIDs := []string{}
+	if len(processTemplate.SupplementaryGroups) > 0 {
+		for _, group := range processTemplate.SupplementaryGroups {
+			groupID, err := lookupGroupID(group)
+			if err != nil {
+				return nil, err
+			}
+			groupIDs = append(groupIDs, groupID)
+		}
+	} else if ids, err := u.GroupIds(); err == nil {
+		groupIDs = ids
+	}
+
+	for _, groupID := range groupIDs {
+		if id, err := parseID(groupID); err == nil {
+			credentials.groups = append(credentials.groups, id)
+		}
+	}
+
+	return credentials, nil
+}
+
+// lookupUser - by name or ID, a numeric ID without an entry is used as is with the same number for its group
+func lookupUser(name string) (*user.User, error) {
+	if len(name) == 0 {
+		return user.Current()
+	}
+
+	if _, err := parseID(name); err == nil {
+		if u, err := user.LookupId(name); err == nil {
+			return u, nil
+		}
+
+		return &user.User{
+			Uid:      name,
+			Gid:      name,
+			Username: name,
+			HomeDir:  "/",
+		}, nil
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: [%s]", contracts.ErrUnknownUser, name)
+	}
+
+	return u, nil
+}
+
+// lookupGroupID - by name or ID, a numeric ID is used as is
+func lookupGroupID(name string) (string, error) {
+	if _, err := parseID(name); err == nil {
+		return name, nil
+	}
+
+	group, err := user.LookupGroup(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: [%s]", contracts.ErrUnknownGroup, name)
+	}
+
+	return group.Gid, nil
+}
+
+func parseID(id string) (uint32, error) {
+	value, err := strconv.ParseUint(id, 10, 32)
+
+	return uint32(value), err
+}
+
+// withUserEnvironment - `environment` with HOME, SHELL, USER and LOGNAME of `u`, `nil` means the current environment
+func withUserEnvironment(environment []string, u *user.User) []string {
+	if environment == nil {
+		environment = os.Environ()
+	}
+
+	userVars := map[string]string{
+		"HOME":    u.HomeDir,
+		"SHELL":   userShell(u.Username),
+		"USER":    u.Username,
+		"LOGNAME": u.Username,
+	}
+
+	result := []string{}
+	for _, env := range environment {
+		if _, ok := userVars[strings.SplitN(env, "=", 2)[0]]; !ok {
+			result = append(result, env)
+		}
+	}
+
+	for _, key := range []string{"HOME", "SHELL", "USER", "LOGNAME"} {
+		result = append(result, key+"="+userVars[key])
+	}
+
+	return result
+}
+
+// userShell - from `/etc/passwd`, `os/user` does not tell, `/bin/sh` if not found
+func userShell(username string) string {
+	file, err := os.Open("/etc/passwd")
+	if err != nil {
+		return "/bin/sh"
+	}
+	defer file.Close()
+
+	// name:password:UID:GID:GECOS:home:shell
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) == 7 && fields[0] == username && len(fields[6]) > 0 {
+			return fields[6]
+		}
+	}
+
+	return "/bin/sh"
+}
@@ -0,0 +1,30 @@
+// +build linux
+
+package internal
+
+import (
+	"github.com/codemodify/systemkit-processes/contracts"
+	"golang.org/x/sys/unix"
+)
+
+// waitForNonChildExit - a pidfd becomes readable when the process exits, needs Linux 5.3+
+func waitForNonChildExit(pid int) contracts.ExitStatus {
+	pidfd, _, errno := unix.Syscall(unix.SYS_PIDFD_OPEN, uintptr(pid), 0, 0)
+	if errno != 0 {
+		return pollForExit(pid)
+	}
+	defer unix.Close(int(pidfd))
+
+	fds := []unix.PollFd{{Fd: int32(pidfd), Events: unix.POLLIN}}
+	for {
+		_, err := unix.Poll(fds, -1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return pollForExit(pid)
+		}
+
+		return unknownExitStatus()
+	}
+}
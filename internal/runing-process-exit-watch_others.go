@@ -0,0 +1,12 @@
+// +build !linux
+
+package internal
+
+import (
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// waitForNonChildExit - no exit notifications for processes that are not our children, poll
+func waitForNonChildExit(pid int) contracts.ExitStatus {
+	return pollForExit(pid)
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// processRun - one execution of the process, from start to exit
+type processRun struct {
+	exited     chan struct{} // closed once the run exited
+	exitStatus contracts.ExitStatus
+	delegates  []exitDelegate
+	watching   bool // a goroutine is waiting for the exit
+	done       bool
+	cgroup     *runCgroup // `nil` unless the template asks for a cgroup
+}
+
+type exitDelegate struct {
+	delegate contracts.ProcessStoppedDelegate
+	params   interface{}
+}
+
+func newProcessRun() *processRun {
+	return &processRun{
+		exited:    make(chan struct{}),
+		delegates: []exitDelegate{},
+	}
+}
+
+// unknownExitStatus - used when the OS does not tell how the process ended
+func unknownExitStatus() contracts.ExitStatus {
+	return contracts.ExitStatus{
+		Code:     -1,
+		Known:    false,
+		ExitedAt: time.Now(),
+	}
+}
+
+func exitStatusFromProcessState(processState *os.ProcessState) contracts.ExitStatus {
+	exitStatus := contracts.ExitStatus{
+		Code:     processState.ExitCode(),
+		Known:    true,
+		ExitedAt: time.Now(),
+	}
+
+	if waitStatus, ok := processState.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() {
+		exitStatus.Signal = int(waitStatus.Signal())
+	}
+
+	return exitStatus
+}
+
+// watchRun - makes sure something waits for `run` to exit, call with `runSync` held
+func (thisRef *runingProcess) watchRun(run *processRun) {
+	if run.watching || run.done {
+		return
+	}
+	run.watching = true
+
+	osProc := thisRef.osCmd.Process
+	isOurChild := thisRef.isOurChild
+	group := run.cgroup
+
+	go func() {
+		exitStatus := contracts.ExitStatus{}
+
+		if isOurChild {
+			// `Process.Wait` and not `Cmd.Wait`, the latter closes STDOUT/STDERR while readers may still drain them
+			processState, err := osProc.Wait()
+			reapedStatus, reaped := reapedExitStatus(osProc.Pid, err)
+			untrackChild(osProc.Pid)
+			if err == nil {
+				exitStatus = exitStatusFromProcessState(processState)
+			} else if reaped {
+				exitStatus = reapedStatus
+			} else {
+				logging.Warningf("%s: wait-FAIL for [%d], [%s]", logID, osProc.Pid, err.Error())
+				isOurChild = false
+			}
+		}
+
+		if !isOurChild {
+			exitStatus = waitForNonChildExit(osProc.Pid)
+		}
+
+		// the group is gone before anyone hears about the exit, a restart can create it again
+		if group != nil {
+			exitStatus = group.finish(exitStatus)
+		}
+
+		thisRef.runExited(run, exitStatus)
+	}()
+}
+
+// runExited - records the exit and calls every registered delegate exactly once
+func (thisRef *runingProcess) runExited(run *processRun, exitStatus contracts.ExitStatus) {
+	thisRef.runSync.Lock()
+	if run.done {
+		thisRef.runSync.Unlock()
+		return
+	}
+
+	run.done = true
+	run.exitStatus = exitStatus
+	delegates := run.delegates
+	run.delegates = []exitDelegate{}
+	if thisRef.run == run {
+		thisRef.stoppedAt = exitStatus.ExitedAt
+	}
+	close(run.exited)
+
+	thisRef.runSync.Unlock()
+
+	logging.Debugf("%s: exited [%s], %d, signal %d", logID, thisRef.processTemplate.Executable, exitStatus.Code, exitStatus.Signal)
+
+	for _, d := range delegates {
+		d.delegate(d.params, exitStatus)
+	}
+}
+
+// currentRun - returns the current run with its exit watched, `nil` if never started
+func (thisRef *runingProcess) currentRun() *processRun {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
+	if thisRef.run != nil {
+		thisRef.watchRun(thisRef.run)
+	}
+
+	return thisRef.run
+}
+
+// waitExit - true if the current run exits within `timeout`
+func (thisRef *runingProcess) waitExit(timeout time.Duration) bool {
+	run := thisRef.currentRun()
+	if run == nil {
+		return true
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-run.exited:
+		return true
+	case <-timer.C:
+	}
+
+	select {
+	case <-run.exited:
+		return true
+	default:
+		return !thisRef.IsRunning()
+	}
+}
+
+// pollForExit - fallback for when the OS can not notify about the exit of a process that is not our child
+func pollForExit(pid int) contracts.ExitStatus {
+	for {
+		rp, err := getRuntimeProcessByPID(pid)
+		if err != nil ||
+			rp.State == contracts.ProcessStateNonExistent ||
+			rp.State == contracts.ProcessStateObsolete ||
+			rp.State == contracts.ProcessStateDead {
+			return unknownExitStatus()
+		}
+
+		time.Sleep(1 * time.Second)
+	}
+}
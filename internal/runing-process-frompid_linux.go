@@ -85,6 +85,7 @@ func getRuntimeProcessByPID(pid int) (contracts.RuntimeProcess, error) {
 	//		environ		-> env vars
 	//		status 		-> Name, Pid, PPid, Uid, Gid
 	//		cmdline		-> full path with args
+	//		limits		-> rlimits in effect
 	//
 	// 		comm		-> executable name
 	//		loginuid 	-> ID of the running-as user
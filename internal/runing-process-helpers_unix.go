@@ -3,7 +3,38 @@
 package internal
 
 import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/codemodify/systemkit-processes/contracts"
 	"golang.org/x/sys/unix"
 )
 
-var procAttrs = &unix.SysProcAttr{}
+func newProcAttrs(processTemplate contracts.ProcessTemplate) *unix.SysProcAttr {
+	return &unix.SysProcAttr{
+		Setsid:  processTemplate.NewSession,
+		Setpgid: processTemplate.NewProcessGroup && !processTemplate.NewSession, // a session leader can't change its group
+	}
+}
+
+// setCredentials - the process starts as the user and groups of `credentials`
+func setCredentials(osCmd *exec.Cmd, credentials *processCredentials) error {
+	osCmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:         credentials.uid,
+		Gid:         credentials.gid,
+		Groups:      credentials.groups,
+		NoSetGroups: credentials.noSetGroups,
+	}
+
+	return nil
+}
+
+// signalProcess - `toGroup` signals the whole process group led by the process
+func signalProcess(osProc *os.Process, signal syscall.Signal, toGroup bool) error {
+	if toGroup {
+		return unix.Kill(-osProc.Pid, signal)
+	}
+
+	return osProc.Signal(signal)
+}
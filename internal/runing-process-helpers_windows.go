@@ -3,12 +3,29 @@
 package internal
 
 import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/codemodify/systemkit-processes/contracts"
 	"golang.org/x/sys/windows"
 )
 
-var procAttrs = &windows.SysProcAttr{
-	CreationFlags: windows.CREATE_UNICODE_ENVIRONMENT |
-		windows.CREATE_NEW_PROCESS_GROUP |
-		windows.CREATE_NEW_CONSOLE |
-		windows.CREATE_NO_WINDOW,
+func newProcAttrs(processTemplate contracts.ProcessTemplate) *windows.SysProcAttr {
+	return &windows.SysProcAttr{
+		CreationFlags: windows.CREATE_UNICODE_ENVIRONMENT |
+			windows.CREATE_NEW_PROCESS_GROUP |
+			windows.CREATE_NEW_CONSOLE |
+			windows.CREATE_NO_WINDOW,
+	}
+}
+
+// setCredentials - Windows needs a token of the user, not supported
+func setCredentials(osCmd *exec.Cmd, credentials *processCredentials) error {
+	return contracts.ErrCredentialsNotSupported
+}
+
+// signalProcess - there are no process group signals on Windows, `toGroup` is ignored
+func signalProcess(osProc *os.Process, signal syscall.Signal, toGroup bool) error {
+	return osProc.Signal(signal)
 }
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"io"
+	"os/exec"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// processIO - STDIN, STDOUT and STDERR of a run, pipes or a pseudo terminal
+type processIO struct {
+	stdOut       io.ReadCloser
+	stdErr       io.ReadCloser  // `nil` in PTY mode, the terminal merges it into `stdOut`
+	stdIn        io.WriteCloser // `nil` unless the template asks for STDIN or for a PTY
+	stdInSources []io.Reader
+	closeStdIn   bool // close STDIN once `stdInSources` are written
+	pty          *pseudoTerminal
+}
+
+// newProcessIO - call after `osCmd.SysProcAttr` is set, PTY mode changes it
+func newProcessIO(osCmd *exec.Cmd, processTemplate contracts.ProcessTemplate) (*processIO, error) {
+	stdInSources, err := stdinSources(processTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	pIO := &processIO{
+		stdInSources: stdInSources,
+		closeStdIn:   (len(stdInSources) > 0 && !processTemplate.StdinOpen),
+	}
+
+	if processTemplate.PTY {
+		pty, err := openPTY(processTemplate.PTYSize)
+		if err != nil {
+			pIO.startFailed()
+			return nil, err
+		}
+
+		attachPTY(osCmd, pty)
+
+		pIO.pty = pty
+		pIO.stdOut = ptyOutput{master: pty.master}
+		pIO.stdIn = newPTYInput(pty.master)
+
+		return pIO, nil
+	}
+
+	// capture STDOUT
+	pIO.stdOut, err = osCmd.StdoutPipe()
+	if err != nil {
+		pIO.startFailed()
+		return nil, err
+	}
+
+	// capture STDERR
+	pIO.stdErr, err = osCmd.StderrPipe()
+	if err != nil {
+		pIO.startFailed()
+		return nil, err
+	}
+
+	// feed STDIN
+	if len(stdInSources) > 0 || processTemplate.StdinOpen {
+		pIO.stdIn, err = osCmd.StdinPipe()
+		if err != nil {
+			pIO.startFailed()
+			return nil, err
+		}
+	}
+
+	return pIO, nil
+}
+
+// started - the child has its own copy of the terminal, ours is not needed
+func (thisRef *processIO) started() {
+	if thisRef.pty != nil {
+		thisRef.pty.tty.Close()
+	}
+}
+
+func (thisRef *processIO) startFailed() {
+	closeStdinSources(thisRef.stdInSources)
+
+	if thisRef.pty != nil {
+		thisRef.pty.tty.Close()
+		thisRef.pty.master.Close()
+	}
+}
+
+// stdinWriter - `nil` if there is no STDIN
+func (thisRef *processIO) stdinWriter() *stdinWriter {
+	if thisRef.stdIn == nil {
+		return nil
+	}
+
+	return newStdinWriter(thisRef.stdIn, thisRef.stdInSources, thisRef.closeStdIn)
+}
@@ -0,0 +1,188 @@
+// +build linux
+
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"golang.org/x/sys/unix"
+)
+
+// rlimit64 - `struct rlimit64` of `prlimit64()`, the same on every architecture
+type rlimit64 struct {
+	cur uint64
+	max uint64
+}
+
+// execStopTimeout - how long a traced process gets to stop after `exec()`
+const execStopTimeout = 5 * time.Second
+
+// stops of traced processes the reaper waited for first, see `waitExecStop`
+var (
+	execStops     = map[int]syscall.Signal{}
+	execStopsSync = &sync.Mutex{}
+)
+
+// traceUntilExec - the process stops right after `exec()`, before its first instruction, for `applyResourceLimits`
+func traceUntilExec(osCmd *exec.Cmd) {
+	osCmd.SysProcAttr.Ptrace = true
+}
+
+// applyResourceLimits - Go can't run code between `fork()` and `exec()`, the process was started traced and is
+// stopped after `exec()`, the limits are set with `prlimit64()` then it is let go, call from the thread that
+// started it, the process is killed and waited for if the limits can't be set
+func applyResourceLimits(osCmd *exec.Cmd, limits contracts.ResourceLimits) error {
+	pid := osCmd.Process.Pid
+
+	err := waitExecStop(pid)
+	if err == nil {
+		err = setResourceLimits(pid, limits)
+	}
+	if err == nil {
+		if detachErr := unix.PtraceDetach(pid); detachErr != nil {
+			err = fmt.Errorf("ptrace-detach: %s", detachErr.Error())
+		}
+	}
+
+	if err != nil {
+		osCmd.Process.Kill()
+		osCmd.Process.Wait()
+	}
+
+	return err
+}
+
+// waitExecStop - waits for the `SIGTRAP` stop after `exec()`, other signals are passed on
+func waitExecStop(pid int) error {
+	deadline := time.Now().Add(execStopTimeout)
+
+	for time.Now().Before(deadline) {
+		waitStatus := unix.WaitStatus(0)
+		waited, err := unix.Wait4(pid, &waitStatus, unix.WNOHANG, nil)
+		if err == unix.EINTR {
+			continue
+		}
+
+		signal := syscall.Signal(0)
+		stopped := false
+
+		if err == nil && waited == pid {
+			if !waitStatus.Stopped() {
+				return fmt.Errorf("exited before exec")
+			}
+			signal, stopped = waitStatus.StopSignal(), true
+		} else {
+			// the reaper can be first to wait
+			execStopsSync.Lock()
+			signal, stopped = execStops[pid]
+			delete(execStops, pid)
+			execStopsSync.Unlock()
+		}
+
+		if !stopped {
+			time.Sleep(time.Millisecond)
+			continue
+		}
+
+		if signal == unix.SIGTRAP {
+			return nil
+		}
+
+		unix.PtraceCont(pid, int(signal))
+	}
+
+	return fmt.Errorf("no stop after exec")
+}
+
+// routeExecStop - called by the reaper for a traced process that stopped
+func routeExecStop(pid int, signal syscall.Signal) {
+	execStopsSync.Lock()
+	defer execStopsSync.Unlock()
+
+	execStops[pid] = signal
+}
+
+// setResourceLimits - `prlimit64()`
+func setResourceLimits(pid int, limits contracts.ResourceLimits) error {
+	resources := []struct {
+		name     string
+		resource int
+		limit    *contracts.ResourceLimit
+	}{
+		{"openFiles", unix.RLIMIT_NOFILE, limits.OpenFiles},
+		{"coreSize", unix.RLIMIT_CORE, limits.CoreSize},
+		{"addressSpace", unix.RLIMIT_AS, limits.AddressSpace},
+		{"cpuTime", unix.RLIMIT_CPU, limits.CPUTime},
+		{"processes", unix.RLIMIT_NPROC, limits.Processes},
+	}
+
+	for _, r := range resources {
+		if r.limit == nil {
+			continue
+		}
+
+		value := rlimit64{cur: r.limit.Soft, max: r.limit.Hard}
+		_, _, errno := unix.Syscall6(unix.SYS_PRLIMIT64, uintptr(pid), uintptr(r.resource), uintptr(unsafe.Pointer(&value)), 0, 0, 0)
+		if errno != 0 {
+			return fmt.Errorf("%s: %s", r.name, errno.Error())
+		}
+	}
+
+	return nil
+}
+
+// readResourceLimits - from `/proc/<pid>/limits`, readable for any process
+func readResourceLimits(pid int) contracts.ResourceLimits {
+	limits := contracts.ResourceLimits{}
+
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/limits", pid))
+	if err != nil {
+		return limits
+	}
+
+	// Limit                     Soft Limit           Hard Limit           Units
+	// Max open files            1024                 524288               files
+	for _, line := range strings.Split(string(data), "\n") {
+		var target **contracts.ResourceLimit
+		switch {
+		case strings.HasPrefix(line, "Max open files"):
+			target = &limits.OpenFiles
+		case strings.HasPrefix(line, "Max core file size"):
+			target = &limits.CoreSize
+		case strings.HasPrefix(line, "Max address space"):
+			target = &limits.AddressSpace
+		case strings.HasPrefix(line, "Max cpu time"):
+			target = &limits.CPUTime
+		case strings.HasPrefix(line, "Max processes"):
+			target = &limits.Processes
+
+		default:
+			continue
+		}
+
+		// the name has spaces, the values are the first two columns after it that parse
+		values := []uint64{}
+		for _, field := range strings.Fields(line) {
+			if field == "unlimited" {
+				values = append(values, contracts.RLimitInfinity)
+			} else if value, err := strconv.ParseUint(field, 10, 64); err == nil {
+				values = append(values, value)
+			}
+		}
+
+		if len(values) >= 2 {
+			*target = &contracts.ResourceLimit{Soft: values[0], Hard: values[1]}
+		}
+	}
+
+	return limits
+}
@@ -0,0 +1,11 @@
+// +build !linux
+
+package internal
+
+import (
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+func readResourceLimits(pid int) contracts.ResourceLimits {
+	return contracts.ResourceLimits{}
+}
@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// keys commonly used by JSON and logfmt loggers, the first one found wins
+var (
+	logLevelKeys   = []string{"level", "lvl", "severity"}
+	logMessageKeys = []string{"msg", "message"}
+	logTimeKeys    = []string{"time", "ts", "timestamp"}
+)
+
+// logParser - turns the lines of one stream into log records
+type logParser struct {
+	config contracts.LogParsing
+	stream contracts.OutputStream
+}
+
+// onOutput - a `contracts.ProcessOutputReader`
+func (thisRef *logParser) onOutput(params interface{}, outputData []byte) {
+	logRecord := parseLogRecord(thisRef.config.Format, outputData)
+	logRecord.Tag = thisRef.config.Tag
+	logRecord.Stream = thisRef.stream
+
+	if thisRef.config.ForwardToLogging {
+		forwardToLogging(logRecord)
+	}
+
+	if thisRef.config.OnRecord != nil {
+		thisRef.config.OnRecord(thisRef.config.OnRecordParams, logRecord)
+	}
+}
+
+// logParsers - readers for STDOUT and STDERR, `nil` when parsing is off
+func (thisRef *runingProcess) logParsers() (contracts.ProcessOutputReader, contracts.ProcessOutputReader) {
+	config := thisRef.processTemplate.LogParsing
+	if config.Format == contracts.LogFormatNone {
+		return nil, nil
+	}
+
+	if thisRef.processTemplate.OutputMode == contracts.OutputModeRaw {
+		logging.Warningf("%s: log-parsing-SKIP for [%s], no lines in raw output mode", logID, thisRef.processTemplate.Executable)
+		return nil, nil
+	}
+
+	stdOutParser := &logParser{config: config, stream: contracts.OutputStreamStdOut}
+	stdErrParser := &logParser{config: config, stream: contracts.OutputStreamStdErr}
+
+	return stdOutParser.onOutput, stdErrParser.onOutput
+}
+
+func parseLogRecord(format contracts.LogFormat, line []byte) contracts.LogRecord {
+	var fields map[string]interface{}
+
+	switch format {
+	case contracts.LogFormatJSON:
+		if err := json.Unmarshal(line, &fields); err != nil {
+			fields = nil
+		}
+	case contracts.LogFormatLogfmt:
+		fields = parseLogfmt(string(line))
+	}
+
+	if len(fields) == 0 {
+		return contracts.LogRecord{
+			Time:    time.Now(),
+			Level:   logging.TypeInfo,
+			Message: string(line),
+			Fields:  map[string]interface{}{},
+			Parsed:  false,
+		}
+	}
+
+	logRecord := contracts.LogRecord{
+		Time:   time.Now(),
+		Level:  logging.TypeInfo,
+		Fields: fields,
+		Parsed: true,
+	}
+
+	if key, value, ok := firstField(fields, logLevelKeys); ok {
+		if level, ok := logLevel(value); ok {
+			logRecord.Level = level
+			delete(fields, key)
+		}
+	}
+
+	if key, value, ok := firstField(fields, logMessageKeys); ok {
+		logRecord.Message = fmt.Sprint(value)
+		delete(fields, key)
+	}
+
+	if key, value, ok := firstField(fields, logTimeKeys); ok {
+		if recordTime, ok := logTime(value); ok {
+			logRecord.Time = recordTime
+			delete(fields, key)
+		}
+	}
+
+	return logRecord
+}
+
+// parseLogfmt - `key=value key="quoted value" flag`, `nil` for plain text
+//
+// Flags are allowed only next to a level or a message key, `listening on port=8080` is plain text
+func parseLogfmt(line string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	pairs := 0
+	flags := 0
+
+	for i := 0; i < len(line); {
+		// skip spaces
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i >= len(line) {
+			break
+		}
+
+		// key
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' {
+			i++
+		}
+		key := line[start:i]
+
+		if i >= len(line) || line[i] == ' ' {
+			fields[key] = true
+			flags++
+			continue
+		}
+		i++ // skip `=`
+
+		// value
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return nil
+			}
+
+			value, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return nil
+			}
+
+			fields[key] = value
+			i = end + 1
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' {
+				i++
+			}
+
+			fields[key] = line[start:i]
+		}
+
+		if len(key) > 0 {
+			pairs++
+		} else {
+			flags++
+		}
+	}
+
+	if pairs == 0 {
+		return nil
+	}
+
+	if flags > 0 {
+		_, _, hasLevel := firstField(fields, logLevelKeys)
+		_, _, hasMessage := firstField(fields, logMessageKeys)
+		if !hasLevel && !hasMessage {
+			return nil
+		}
+	}
+
+	return fields
+}
+
+func firstField(fields map[string]interface{}, keys []string) (string, interface{}, bool) {
+	for _, key := range keys {
+		if value, ok := fields[key]; ok {
+			return key, value, true
+		}
+	}
+
+	return "", nil, false
+}
+
+// logLevel - names used by most loggers, and the numbers used by `pino` and `bunyan`
+func logLevel(value interface{}) (logging.LogType, bool) {
+	if number, ok := value.(float64); ok {
+		switch {
+		case number >= 60:
+			return logging.TypeFatal, true
+		case number >= 50:
+			return logging.TypeError, true
+		case number >= 40:
+			return logging.TypeWarning, true
+		case number >= 30:
+			return logging.TypeInfo, true
+		case number >= 20:
+			return logging.TypeDebug, true
+		default:
+			return logging.TypeTrace, true
+		}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(fmt.Sprint(value))) {
+	case "trace":
+		return logging.TypeTrace, true
+	case "debug", "dbug":
+		return logging.TypeDebug, true
+	case "info", "information", "notice":
+		return logging.TypeInfo, true
+	case "success":
+		return logging.TypeSuccess, true
+	case "warn", "warning":
+		return logging.TypeWarning, true
+	case "error", "err", "eror":
+		return logging.TypeError, true
+	case "fatal", "critical", "crit", "alert", "emergency":
+		return logging.TypeFatal, true
+	case "panic":
+		return logging.TypePanic, true
+	}
+
+	return logging.TypeInfo, false
+}
+
+// logTime - RFC 3339 strings or UNIX seconds
+func logTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case float64:
+		seconds, fraction := math.Modf(v)
+		return time.Unix(int64(seconds), int64(fraction*1e9)), true
+
+	case string:
+		if recordTime, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return recordTime, true
+		}
+
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			return logTime(seconds)
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// forwardToLogging - `systemkit-logging` has no fields, they are added to the message
+func forwardToLogging(logRecord contracts.LogRecord) {
+	keys := make([]string, 0, len(logRecord.Fields))
+	for key := range logRecord.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	message := logRecord.Tag + ": " + logRecord.Message
+	for _, key := range keys {
+		message += fmt.Sprintf(" %s=%v", key, logRecord.Fields[key])
+	}
+
+	switch logRecord.Level {
+	case logging.TypeTrace:
+		logging.Trace(message)
+	case logging.TypePanic:
+		logging.Panic(message)
+	case logging.TypeFatal:
+		logging.Fatal(message)
+	case logging.TypeError:
+		logging.Error(message)
+	case logging.TypeWarning:
+		logging.Warning(message)
+	case logging.TypeSuccess:
+		logging.Success(message)
+	case logging.TypeDebug:
+		logging.Debug(message)
+
+	default:
+		logging.Info(message)
+	}
+}
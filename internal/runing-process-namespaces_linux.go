@@ -0,0 +1,121 @@
+// +build linux
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"golang.org/x/sys/unix"
+)
+
+// setNamespaces - the process is cloned into new namespaces, call after `newProcAttrs`
+func setNamespaces(osCmd *exec.Cmd, namespaces contracts.Namespaces) error {
+	flags := []struct {
+		enabled bool
+		flag    uintptr
+	}{
+		{namespaces.PID, unix.CLONE_NEWPID},
+		{namespaces.Mount, unix.CLONE_NEWNS},
+		{namespaces.Network, unix.CLONE_NEWNET},
+		{namespaces.UTS, unix.CLONE_NEWUTS},
+		{namespaces.IPC, unix.CLONE_NEWIPC},
+		{namespaces.User, unix.CLONE_NEWUSER},
+	}
+
+	for _, f := range flags {
+		if f.enabled {
+			osCmd.SysProcAttr.Cloneflags |= f.flag
+		}
+	}
+
+	if namespaces.User {
+		osCmd.SysProcAttr.UidMappings = idMappings(namespaces.UIDMappings, os.Geteuid())
+		osCmd.SysProcAttr.GidMappings = idMappings(namespaces.GIDMappings, os.Getegid())
+
+		// the caller may not be mapped, start as the first mapped IDs unless a user is asked for
+		if osCmd.SysProcAttr.Credential == nil {
+			osCmd.SysProcAttr.Credential = &syscall.Credential{
+				Uid:         uint32(osCmd.SysProcAttr.UidMappings[0].ContainerID),
+				Gid:         uint32(osCmd.SysProcAttr.GidMappings[0].ContainerID),
+				NoSetGroups: true,
+			}
+		}
+	}
+
+	osCmd.SysProcAttr.Chroot = namespaces.RootDirectory
+
+	return nil
+}
+
+// idMappings - root inside is `hostID` when nothing is mapped
+func idMappings(mappings []contracts.IDMapping, hostID int) []syscall.SysProcIDMap {
+	if len(mappings) == 0 {
+		return []syscall.SysProcIDMap{{ContainerID: 0, HostID: hostID, Size: 1}}
+	}
+
+	result := []syscall.SysProcIDMap{}
+	for _, mapping := range mappings {
+		result = append(result, syscall.SysProcIDMap{
+			ContainerID: mapping.ContainerID,
+			HostID:      mapping.HostID,
+			Size:        mapping.Size,
+		})
+	}
+
+	return result
+}
+
+// startProcess - `osCmd.Start()`, from a thread of its own for a hostname, a parent death signal or resource limits
+//
+// For a hostname the thread gets a new UTS namespace and the process inherits it with the hostname already set,
+// the parent death signal is sent when the thread that started the process ends, not the supervisor,
+// so the thread is kept until `exited` is closed, then it ends with its namespace,
+// resource limits are set by the thread, it traces the process until `exec()`
+func startProcess(osCmd *exec.Cmd, namespaces contracts.Namespaces, limits contracts.ResourceLimits, exited <-chan struct{}) error {
+	if len(namespaces.Hostname) == 0 && osCmd.SysProcAttr.Pdeathsig == 0 && limits.IsEmpty() {
+		return osCmd.Start()
+	}
+
+	if !limits.IsEmpty() {
+		traceUntilExec(osCmd)
+	}
+
+	started := make(chan error, 1)
+
+	go func() {
+		// never unlocked, Go ends the thread with the goroutine
+		runtime.LockOSThread()
+
+		if len(namespaces.Hostname) > 0 {
+			if err := unix.Unshare(unix.CLONE_NEWUTS); err != nil {
+				started <- fmt.Errorf("unshare: %w", err)
+				return
+			}
+
+			if err := unix.Sethostname([]byte(namespaces.Hostname)); err != nil {
+				started <- fmt.Errorf("sethostname: %w", err)
+				return
+			}
+		}
+
+		err := osCmd.Start()
+		if err == nil && !limits.IsEmpty() {
+			if limitsErr := applyResourceLimits(osCmd, limits); limitsErr != nil {
+				err = fmt.Errorf("resource-limits-FAIL, %s", limitsErr.Error())
+			}
+		}
+		started <- err
+		if err != nil {
+			return
+		}
+
+		<-exited
+	}()
+
+	return <-started
+}
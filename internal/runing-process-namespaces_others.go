@@ -0,0 +1,25 @@
+// +build !linux
+
+package internal
+
+import (
+	"os/exec"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+func setNamespaces(osCmd *exec.Cmd, namespaces contracts.Namespaces) error {
+	if namespaces.IsEmpty() {
+		return nil
+	}
+
+	return contracts.ErrNamespacesNotSupported
+}
+
+func startProcess(osCmd *exec.Cmd, namespaces contracts.Namespaces, limits contracts.ResourceLimits, exited <-chan struct{}) error {
+	if !limits.IsEmpty() {
+		return contracts.ErrResourceLimitsNotSupported
+	}
+
+	return osCmd.Start()
+}
@@ -0,0 +1,232 @@
+// +build linux
+
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+	"golang.org/x/sys/unix"
+)
+
+// reapInterval - SIGCHLD of several exits can come as one, look again from time to time
+const reapInterval = 5 * time.Second
+
+// orphanScanInterval - the parents are remembered from one scan to the next, an orphan seen before it was
+// adopted is known to be one
+const orphanScanInterval = time.Second
+
+var (
+	subreaperOnce = &sync.Once{}
+	subreaperErr  error
+	reaperOnce    = &sync.Once{}
+	reaperErr     error
+
+	// for `reapOrphans`, guarded by `childrenSync`
+	lastScan     = map[int]procStat{}
+	orphanGroups = map[int]bool{} // process groups of monitored processes, other than ours
+)
+
+// procStat - from `/proc/<pid>/stat`
+type procStat struct {
+	ppid      int
+	pgid      int
+	startTime uint64 // tells a reused PID apart
+}
+
+// setParentDeathSignal - the kernel sends `signalName` to the process when the thread that started it ends
+func setParentDeathSignal(osCmd *exec.Cmd, signalName string) error {
+	if len(signalName) == 0 {
+		return nil
+	}
+
+	signal := signalByName(signalName)
+	if signal == 0 {
+		return errUnknownSignal(signalName)
+	}
+
+	osCmd.SysProcAttr.Pdeathsig = signal
+
+	return nil
+}
+
+// EnableSubreaper - orphaned descendants are reparented to this process instead of init, and reaped once they exit
+//
+// Only children that were adopted are reaped, the ones seen with another parent before, or in the process group
+// of a monitored process that is not the group of this process, an orphan adopted between two scans in the group
+// of this process is left alone, as it can't be told apart from a child started elsewhere in this process
+func EnableSubreaper() error {
+	subreaperOnce.Do(func() {
+		if err := unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0); err != nil {
+			subreaperErr = fmt.Errorf("%w: %s", contracts.ErrSubreaperNotSupported, err.Error())
+			return
+		}
+
+		go onChildExits(reapOrphans, orphanScanInterval)
+
+		logging.Debugf("%s: subreaper-ENABLED", logID)
+	})
+
+	return subreaperErr
+}
+
+// EnableReaper - for a supervisor that runs as PID 1, every child that exits is reaped with `wait4(-1)`
+//
+// Tracked children get their exit status from the reaper when it is first to wait, orphans are reparented here
+// like with `EnableSubreaper`, children of this process not started through this package can't be waited for
+func EnableReaper() error {
+	reaperOnce.Do(func() {
+		// PID 1 gets the orphans anyway
+		if os.Getpid() != 1 {
+			if err := unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0); err != nil {
+				reaperErr = fmt.Errorf("%w: %s", contracts.ErrSubreaperNotSupported, err.Error())
+				return
+			}
+		}
+
+		go onChildExits(reapAll, reapInterval)
+
+		logging.Debugf("%s: reaper-ENABLED", logID)
+	})
+
+	return reaperErr
+}
+
+// onChildExits - calls `reap` on SIGCHLD and every `interval`, never returns
+func onChildExits(reap func(), interval time.Duration) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, unix.SIGCHLD)
+
+	ticker := time.NewTicker(interval)
+	for {
+		select {
+		case <-signals:
+		case <-ticker.C:
+		}
+
+		reap()
+	}
+}
+
+// reapAll - every child that exited, the tracked ones get their exit status routed
+func reapAll() {
+	for {
+		waitStatus := unix.WaitStatus(0)
+		pid, err := unix.Wait4(-1, &waitStatus, unix.WNOHANG, nil)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil || pid <= 0 {
+			return
+		}
+
+		// traced until `exec()` for its resource limits, not an exit
+		if waitStatus.Stopped() {
+			routeExecStop(pid, waitStatus.StopSignal())
+			continue
+		}
+
+		exitStatus := contracts.ExitStatus{
+			Code:     waitStatus.ExitStatus(),
+			Known:    true,
+			ExitedAt: time.Now(),
+		}
+		if waitStatus.Signaled() {
+			exitStatus.Signal = int(waitStatus.Signal())
+		}
+
+		if !routeExit(pid, exitStatus) {
+			logging.Debugf("%s: reap-ORPHAN [%d], %d, signal %d", logID, pid, exitStatus.Code, exitStatus.Signal)
+		}
+	}
+}
+
+// reapOrphans - waits for the adopted children that exited, children started elsewhere in this process are left
+// to whoever started them
+func reapOrphans() {
+	current := readProcStats()
+	pid := os.Getpid()
+	pgid := unix.Getpgrp()
+
+	childrenSync.Lock()
+	defer childrenSync.Unlock()
+
+	// the groups stay known while they have members, the monitored process may be gone
+	groups := map[int]bool{}
+	for _, stat := range current {
+		if orphanGroups[stat.pgid] {
+			groups[stat.pgid] = true
+		}
+	}
+	for child := range children {
+		if stat, ok := current[child]; ok && stat.pgid != pgid {
+			groups[stat.pgid] = true
+		}
+	}
+
+	for orphanPID, stat := range current {
+		if _, tracked := children[orphanPID]; tracked || stat.ppid != pid {
+			continue
+		}
+
+		previous, seen := lastScan[orphanPID]
+		reparented := seen && previous.startTime == stat.startTime && previous.ppid != pid
+		if !reparented && !groups[stat.pgid] {
+			continue
+		}
+
+		waitStatus := unix.WaitStatus(0)
+		if reaped, _ := unix.Wait4(orphanPID, &waitStatus, unix.WNOHANG, nil); reaped == orphanPID {
+			logging.Debugf("%s: reap-ORPHAN [%d], %d, signal %d", logID, orphanPID, waitStatus.ExitStatus(), int(waitStatus.Signal()))
+		}
+	}
+
+	lastScan = current
+	orphanGroups = groups
+}
+
+// readProcStats - every process, those gone while reading are skipped
+func readProcStats() map[int]procStat {
+	stats := map[int]procStat{}
+
+	names, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		logging.Warningf("%s: reap-FAIL, [%s]", logID, err.Error())
+		return stats
+	}
+
+	for _, name := range names {
+		pid, err := strconv.Atoi(name.Name())
+		if err != nil {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue
+		}
+
+		// the name can have spaces and parentheses, the fields after it start with the state
+		fields := strings.Fields(string(data[strings.LastIndex(string(data), ")")+1:]))
+		if len(fields) < 20 {
+			continue
+		}
+
+		stat := procStat{}
+		stat.ppid, _ = strconv.Atoi(fields[1])
+		stat.pgid, _ = strconv.Atoi(fields[2])
+		stat.startTime, _ = strconv.ParseUint(fields[19], 10, 64)
+		stats[pid] = stat
+	}
+
+	return stats
+}
@@ -0,0 +1,27 @@
+// +build !linux
+
+package internal
+
+import (
+	"os/exec"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+func setParentDeathSignal(osCmd *exec.Cmd, signalName string) error {
+	if len(signalName) == 0 {
+		return nil
+	}
+
+	return contracts.ErrParentDeathSignalNotSupported
+}
+
+// EnableSubreaper - Linux only
+func EnableSubreaper() error {
+	return contracts.ErrSubreaperNotSupported
+}
+
+// EnableReaper - Linux only
+func EnableReaper() error {
+	return contracts.ErrSubreaperNotSupported
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"sync"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// defaultOutputSubscriberBuffer - lines queued for a subscriber that did not ask for a size
+const defaultOutputSubscriberBuffer = 64
+
+// outputHub - fan-out of every output line to any number of subscribers, kept across restarts
+type outputHub struct {
+	subscribers     map[int]*outputSubscriber
+	subscribersSync *sync.Mutex
+	nextID          int
+}
+
+type outputSubscriber struct {
+	subscription contracts.OutputSubscription
+	lines        chan contracts.OutputLine
+	unsubscribed chan struct{} // closed first, releases a blocked send
+	closed       bool
+	sendSync     *sync.Mutex // held while sending, `lines` is closed only under it
+}
+
+func newOutputHub() *outputHub {
+	return &outputHub{
+		subscribers:     map[int]*outputSubscriber{},
+		subscribersSync: &sync.Mutex{},
+		nextID:          0,
+	}
+}
+
+func (thisRef *outputHub) subscribe(subscription contracts.OutputSubscription) (<-chan contracts.OutputLine, func()) {
+	bufferSize := subscription.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultOutputSubscriberBuffer
+	}
+
+	subscriber := &outputSubscriber{
+		subscription: subscription,
+		lines:        make(chan contracts.OutputLine, bufferSize),
+		unsubscribed: make(chan struct{}),
+		sendSync:     &sync.Mutex{},
+	}
+
+	thisRef.subscribersSync.Lock()
+	id := thisRef.nextID
+	thisRef.nextID++
+	thisRef.subscribers[id] = subscriber
+	thisRef.subscribersSync.Unlock()
+
+	unsubscribeOnce := &sync.Once{}
+	unsubscribe := func() {
+		unsubscribeOnce.Do(func() {
+			thisRef.subscribersSync.Lock()
+			delete(thisRef.subscribers, id)
+			thisRef.subscribersSync.Unlock()
+
+			close(subscriber.unsubscribed)
+
+			subscriber.sendSync.Lock()
+			subscriber.closed = true
+			close(subscriber.lines)
+			subscriber.sendSync.Unlock()
+		})
+	}
+
+	return subscriber.lines, unsubscribe
+}
+
+// publish - blocks only for the subscribers that asked for it
+func (thisRef *outputHub) publish(line contracts.OutputLine) {
+	thisRef.subscribersSync.Lock()
+	subscribers := make([]*outputSubscriber, 0, len(thisRef.subscribers))
+	for _, subscriber := range thisRef.subscribers {
+		if subscriber.subscription.Stream == line.Stream {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	thisRef.subscribersSync.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber.send(line)
+	}
+}
+
+func (thisRef *outputSubscriber) send(line contracts.OutputLine) {
+	thisRef.sendSync.Lock()
+	defer thisRef.sendSync.Unlock()
+
+	if thisRef.closed {
+		return
+	}
+
+	switch thisRef.subscription.Backpressure {
+	case contracts.OutputBackpressureBlock:
+		select {
+		case thisRef.lines <- line:
+		case <-thisRef.unsubscribed:
+		}
+
+	case contracts.OutputBackpressureDropOldest:
+		for {
+			select {
+			case thisRef.lines <- line:
+				return
+			default:
+			}
+
+			select {
+			case dropped := <-thisRef.lines:
+				logging.Debugf("%s: output-DROP-OLDEST %s line %d, subscriber is full", logID, dropped.Stream, dropped.Sequence)
+			default:
+			}
+		}
+
+	default:
+		select {
+		case thisRef.lines <- line:
+		default:
+			logging.Debugf("%s: output-DROP-NEWEST %s line %d, subscriber is full", logID, line.Stream, line.Sequence)
+		}
+	}
+}
+
+// SubscribeOutput - every new line of one stream, across restarts, call the returned func to unsubscribe
+func (thisRef *runingProcess) SubscribeOutput(subscription contracts.OutputSubscription) (<-chan contracts.OutputLine, func()) {
+	return thisRef.subscribers.subscribe(subscription)
+}
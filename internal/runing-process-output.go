@@ -0,0 +1,270 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// defaultOutputLines -
+const (
+	defaultOutputLines   = 1000
+	defaultOutputBytes   = 1024 * 1024
+	defaultMaxLineLength = 64 * 1024
+)
+
+// outputBuffer - the last lines of STDOUT and STDERR, kept across restarts
+type outputBuffer struct {
+	lines     []contracts.OutputLine
+	bytes     int
+	nextSeq   int64
+	maxLines  int
+	maxBytes  int
+	changed   chan struct{} // closed and replaced on every new line
+	linesSync *sync.Mutex
+}
+
+func newOutputBuffer(size contracts.OutputBufferSize) *outputBuffer {
+	maxLines := size.Lines
+	if maxLines == 0 {
+		maxLines = defaultOutputLines
+	}
+
+	maxBytes := size.Bytes
+	if maxBytes == 0 {
+		maxBytes = defaultOutputBytes
+	}
+
+	return &outputBuffer{
+		lines:     []contracts.OutputLine{},
+		maxLines:  maxLines,
+		maxBytes:  maxBytes,
+		changed:   make(chan struct{}),
+		linesSync: &sync.Mutex{},
+	}
+}
+
+// append - keeps `data`, drops the oldest lines past the limits
+func (thisRef *outputBuffer) append(stream contracts.OutputStream, data []byte, truncated bool) contracts.OutputLine {
+	thisRef.linesSync.Lock()
+	defer thisRef.linesSync.Unlock()
+
+	line := contracts.OutputLine{
+		Sequence:  thisRef.nextSeq,
+		Stream:    stream,
+		Time:      time.Now(),
+		Data:      data,
+		Truncated: truncated,
+	}
+
+	thisRef.lines = append(thisRef.lines, line)
+	thisRef.bytes += len(data)
+	thisRef.nextSeq++
+
+	drop := 0
+	for drop < len(thisRef.lines) &&
+		(len(thisRef.lines)-drop > thisRef.maxLines || thisRef.bytes > thisRef.maxBytes) {
+		thisRef.bytes -= len(thisRef.lines[drop].Data)
+		drop++
+	}
+	if drop > 0 {
+		thisRef.lines = append([]contracts.OutputLine{}, thisRef.lines[drop:]...)
+	}
+
+	close(thisRef.changed)
+	thisRef.changed = make(chan struct{})
+
+	return line
+}
+
+// tail - the last `count` lines, all of them if `count` is not positive
+func (thisRef *outputBuffer) tail(count int) []contracts.OutputLine {
+	thisRef.linesSync.Lock()
+	defer thisRef.linesSync.Unlock()
+
+	first := 0
+	if count > 0 && count < len(thisRef.lines) {
+		first = len(thisRef.lines) - count
+	}
+
+	return append([]contracts.OutputLine{}, thisRef.lines[first:]...)
+}
+
+// since - lines with a sequence from `sequence` on, and a channel closed once there are more
+func (thisRef *outputBuffer) since(sequence int64) ([]contracts.OutputLine, <-chan struct{}) {
+	thisRef.linesSync.Lock()
+	defer thisRef.linesSync.Unlock()
+
+	lines := []contracts.OutputLine{}
+	for _, line := range thisRef.lines {
+		if line.Sequence >= sequence {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, thisRef.changed
+}
+
+// nextSequence - the sequence the next line will get
+func (thisRef *outputBuffer) nextSequence() int64 {
+	thisRef.linesSync.Lock()
+	defer thisRef.linesSync.Unlock()
+
+	return thisRef.nextSeq
+}
+
+// outputStream - pumps STDOUT or STDERR of one run into the buffer, to the `OnStdOut`/`OnStdErr` readers and to the subscribers
+type outputStream struct {
+	stream       contracts.OutputStream
+	buffer       *outputBuffer
+	subscribers  *outputHub
+	firstSeq     int64 // lines of this run start here, replayed to late readers
+	readers      []outputReader
+	dispatchSync *sync.Mutex
+}
+
+type outputReader struct {
+	reader      contracts.ProcessOutputReader
+	params      interface{}
+	deliverSync *sync.Mutex // held while the reader is called, keeps the replay before the new lines
+}
+
+func (thisRef outputReader) deliver(data []byte) {
+	thisRef.deliverSync.Lock()
+	defer thisRef.deliverSync.Unlock()
+
+	thisRef.reader(thisRef.params, data)
+}
+
+func newOutputStream(stream contracts.OutputStream, buffer *outputBuffer, subscribers *outputHub) *outputStream {
+	return &outputStream{
+		stream:       stream,
+		buffer:       buffer,
+		subscribers:  subscribers,
+		firstSeq:     buffer.nextSequence(),
+		readers:      []outputReader{},
+		dispatchSync: &sync.Mutex{},
+	}
+}
+
+// pump - drains `readerCloser` until EOF, the pipe is never left full when nobody reads
+func (thisRef *outputStream) pump(readerCloser io.ReadCloser, processTemplate contracts.ProcessTemplate, pumps *sync.WaitGroup) {
+	defer pumps.Done()
+
+	var err error
+	if processTemplate.OutputMode == contracts.OutputModeRaw {
+		err = readChunks(readerCloser, func(chunk []byte) {
+			thisRef.dispatch(chunk, false)
+		})
+	} else {
+		maxLineLength := processTemplate.MaxLineLength
+		if maxLineLength <= 0 {
+			maxLineLength = defaultMaxLineLength
+		}
+
+		err = readLines(readerCloser, maxLineLength, func(line []byte, truncated bool) {
+			if truncated {
+				logging.Warningf("%s: read-%s-TRUNCATED for [%s], line longer than %d bytes", logID, thisRef.stream, processTemplate.Executable, maxLineLength)
+			}
+
+			thisRef.dispatch(line, truncated)
+		})
+	}
+	readerCloser.Close()
+
+	if err != nil {
+		logging.Warningf("%s: read-%s-FAIL for [%s], [%s]", logID, thisRef.stream, processTemplate.Executable, err.Error())
+	}
+
+	logging.Debugf("%s: read-%s-SUCESS for [%s]", logID, thisRef.stream, processTemplate.Executable)
+}
+
+func (thisRef *outputStream) dispatch(outputData []byte, truncated bool) {
+	// the reader reuses its buffer
+	data := append([]byte{}, outputData...)
+
+	// readers are called outside of the lock, they can add readers
+	thisRef.dispatchSync.Lock()
+	line := thisRef.buffer.append(thisRef.stream, data, truncated)
+	readers := append([]outputReader{}, thisRef.readers...)
+	thisRef.dispatchSync.Unlock()
+
+	for _, r := range readers {
+		r.deliver(data)
+	}
+
+	thisRef.subscribers.publish(line)
+}
+
+// addReader - replays what this run wrote so far, then passes on new lines
+func (thisRef *outputStream) addReader(reader contracts.ProcessOutputReader, params interface{}) {
+	r := outputReader{
+		reader:      reader,
+		params:      params,
+		deliverSync: &sync.Mutex{},
+	}
+
+	// a line is either replayed or dispatched, new lines wait for the replay
+	r.deliverSync.Lock()
+	defer r.deliverSync.Unlock()
+
+	thisRef.dispatchSync.Lock()
+	lines, _ := thisRef.buffer.since(thisRef.firstSeq)
+	thisRef.readers = append(thisRef.readers, r)
+	thisRef.dispatchSync.Unlock()
+
+	for _, line := range lines {
+		if line.Stream == thisRef.stream {
+			reader(params, line.Data)
+		}
+	}
+}
+
+// Output - the last `tail` lines of STDOUT and STDERR, all retained lines if `tail` is not positive
+func (thisRef *runingProcess) Output(tail int) []contracts.OutputLine {
+	return thisRef.output.tail(tail)
+}
+
+// FollowOutput - the last `tail` lines then every new one, like `tail -f`, until `ctx` is done
+func (thisRef *runingProcess) FollowOutput(ctx context.Context, tail int) <-chan contracts.OutputLine {
+	follow := make(chan contracts.OutputLine)
+
+	next := thisRef.output.nextSequence()
+	if tail > 0 {
+		if lines := thisRef.output.tail(tail); len(lines) > 0 {
+			next = lines[0].Sequence
+		}
+	}
+
+	go func() {
+		defer close(follow)
+
+		for {
+			lines, changed := thisRef.output.since(next)
+			for _, line := range lines {
+				select {
+				case follow <- line:
+					next = line.Sequence + 1
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if len(lines) > 0 {
+				continue
+			}
+
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return follow
+}
@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// pseudoTerminal - `master` stays with us, `tty` becomes STDIN, STDOUT, STDERR and the controlling terminal of the child
+type pseudoTerminal struct {
+	master *os.File
+	tty    *os.File
+}
+
+// ptyOutput - reading the master once the child is gone gives EIO, that is the EOF of a terminal
+type ptyOutput struct {
+	master *os.File
+}
+
+func (thisRef ptyOutput) Read(p []byte) (int, error) {
+	n, err := thisRef.master.Read(p)
+	if err != nil && errors.Is(err, syscall.EIO) {
+		return n, io.EOF
+	}
+
+	return n, err
+}
+
+func (thisRef ptyOutput) Close() error {
+	return thisRef.master.Close()
+}
+
+// ptyInput - closing sends CTRL+D instead of hanging up the terminal
+type ptyInput struct {
+	master    *os.File
+	lastByte  byte // CTRL+D is EOF only at the start of a line, otherwise it just hands over the line
+	writeSync *sync.Mutex
+}
+
+func newPTYInput(master *os.File) *ptyInput {
+	return &ptyInput{
+		master:    master,
+		lastByte:  '\n',
+		writeSync: &sync.Mutex{},
+	}
+}
+
+func (thisRef *ptyInput) Write(p []byte) (int, error) {
+	thisRef.writeSync.Lock()
+	defer thisRef.writeSync.Unlock()
+
+	n, err := thisRef.master.Write(p)
+	if n > 0 {
+		thisRef.lastByte = p[n-1]
+	}
+
+	return n, err
+}
+
+func (thisRef *ptyInput) Close() error {
+	thisRef.writeSync.Lock()
+	defer thisRef.writeSync.Unlock()
+
+	const ctrlD = 4
+
+	eof := []byte{ctrlD}
+	if thisRef.lastByte != '\n' {
+		eof = []byte{'\n', ctrlD}
+	}
+
+	_, err := thisRef.master.Write(eof)
+	return err
+}
+
+// ResizePTY - changes the window size of the terminal, the child gets SIGWINCH
+func (thisRef *runingProcess) ResizePTY(size contracts.PTYSize) error {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
+	if thisRef.pty == nil {
+		return contracts.ErrPTYNotAvailable
+	}
+
+	return setPTYSize(thisRef.pty.master, size)
+}
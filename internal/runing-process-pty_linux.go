@@ -0,0 +1,78 @@
+// +build linux
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"golang.org/x/sys/unix"
+)
+
+// used when the template has no size
+const (
+	defaultPTYRows = 24
+	defaultPTYCols = 80
+)
+
+func openPTY(size contracts.PTYSize) (*pseudoTerminal, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// unlock the tty side and find its name
+	if err := unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	ttyIndex, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	tty, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", ttyIndex), os.O_RDWR|syscall.O_NOCTTY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	if size.Rows == 0 || size.Cols == 0 {
+		size = contracts.PTYSize{Rows: defaultPTYRows, Cols: defaultPTYCols}
+	}
+
+	if err := setPTYSize(master, size); err != nil {
+		tty.Close()
+		master.Close()
+		return nil, err
+	}
+
+	return &pseudoTerminal{
+		master: master,
+		tty:    tty,
+	}, nil
+}
+
+// attachPTY - the child starts a new session with the tty as its controlling terminal
+func attachPTY(osCmd *exec.Cmd, pty *pseudoTerminal) {
+	osCmd.Stdin = pty.tty
+	osCmd.Stdout = pty.tty
+	osCmd.Stderr = pty.tty
+
+	osCmd.SysProcAttr.Setsid = true
+	osCmd.SysProcAttr.Setpgid = false // a session leader can't change its group
+	osCmd.SysProcAttr.Setctty = true
+	osCmd.SysProcAttr.Ctty = 0 // FD of the tty in the child, it is STDIN
+}
+
+func setPTYSize(master *os.File, size contracts.PTYSize) error {
+	return unix.IoctlSetWinsize(int(master.Fd()), unix.TIOCSWINSZ, &unix.Winsize{
+		Row: size.Rows,
+		Col: size.Cols,
+	})
+}
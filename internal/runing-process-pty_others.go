@@ -0,0 +1,20 @@
+// +build !linux
+
+package internal
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+func openPTY(size contracts.PTYSize) (*pseudoTerminal, error) {
+	return nil, contracts.ErrPTYNotAvailable
+}
+
+func attachPTY(osCmd *exec.Cmd, pty *pseudoTerminal) {}
+
+func setPTYSize(master *os.File, size contracts.PTYSize) error {
+	return contracts.ErrPTYNotAvailable
+}
@@ -0,0 +1,30 @@
+// +build !windows
+
+package internal
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// sendSignal - sends the signal named `signalName`, like `SIGQUIT`
+func sendSignal(osProc *os.Process, signalName string, toGroup bool) error {
+	signal := signalByName(signalName)
+	if signal == 0 {
+		return errUnknownSignal(signalName)
+	}
+
+	return signalProcess(osProc, signal, toGroup)
+}
+
+// signalByName - `SIGQUIT`, `sigquit` or `QUIT`, 0 if unknown
+func signalByName(signalName string) unix.Signal {
+	name := strings.ToUpper(strings.TrimSpace(signalName))
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	return unix.SignalNum(name)
+}
@@ -0,0 +1,26 @@
+// +build windows
+
+package internal
+
+import (
+	"os"
+	"strings"
+)
+
+// sendSignal - Windows has no signals, `SIGINT` is sent as CTRL+C and `SIGKILL`/`SIGTERM` kill the process
+func sendSignal(osProc *os.Process, signalName string, toGroup bool) error {
+	name := strings.ToUpper(strings.TrimSpace(signalName))
+	if !strings.HasPrefix(name, "SIG") {
+		name = "SIG" + name
+	}
+
+	switch name {
+	case "SIGINT":
+		return sendCtrlC(osProc.Pid)
+	case "SIGTERM", "SIGKILL":
+		return osProc.Kill()
+
+	default:
+		return errUnknownSignal(signalName)
+	}
+}
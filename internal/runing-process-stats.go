@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// Stats - resource usage of the current run, the CPU percent is measured from the previous call
+func (thisRef *runingProcess) Stats() (contracts.ProcessStats, error) {
+	if !thisRef.IsRunning() {
+		return contracts.ProcessStats{}, contracts.ErrProcessDoesNotExist
+	}
+
+	pid := thisRef.processID()
+
+	stats, err := readProcessStats(pid)
+	if err != nil {
+		return contracts.ProcessStats{}, err
+	}
+	stats.Time = time.Now()
+	stats.ProcessID = pid
+
+	thisRef.statsSync.Lock()
+	defer thisRef.statsSync.Unlock()
+
+	previous := thisRef.lastStats
+	if previous.ProcessID == pid && stats.Time.After(previous.Time) {
+		stats.CPUPercent = cpuPercent(
+			(stats.CPUUser+stats.CPUSystem)-(previous.CPUUser+previous.CPUSystem),
+			stats.Time.Sub(previous.Time),
+		)
+	} else if startedAt := thisRef.StartedAt(); startedAt.After(time.Unix(0, 0)) {
+		stats.CPUPercent = cpuPercent(stats.CPUUser+stats.CPUSystem, stats.Time.Sub(startedAt))
+	}
+
+	thisRef.lastStats = stats
+
+	return stats, nil
+}
+
+// CgroupStats - usage of the cgroup of the current run, the process and everything it started
+func (thisRef *runingProcess) CgroupStats() (contracts.CgroupStats, error) {
+	thisRef.runSync.Lock()
+	run := thisRef.run
+	available := run != nil && run.cgroup != nil && !run.done
+	thisRef.runSync.Unlock()
+
+	if !available {
+		return contracts.CgroupStats{}, contracts.ErrCgroupNotAvailable
+	}
+
+	return run.cgroup.stats(), nil
+}
+
+func cpuPercent(cpu time.Duration, wall time.Duration) float64 {
+	if wall <= 0 || cpu < 0 {
+		return 0
+	}
+
+	return 100 * float64(cpu) / float64(wall)
+}
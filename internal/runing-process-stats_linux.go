@@ -0,0 +1,125 @@
+// +build linux
+
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// clockTicksPerSecond - `sysconf(_SC_CLK_TCK)`, 100 on every Linux that matters, can not be read without cgo
+const clockTicksPerSecond = 100
+
+func readProcessStats(pid int) (contracts.ProcessStats, error) {
+	folder := fmt.Sprintf("/proc/%d", pid)
+
+	//
+	// /proc/%d/*
+	// 		stat		-> CPU times, threads
+	//		statm		-> VSZ, RSS in pages
+	//		io			-> read and write bytes, only for the same user
+	//		fd			-> one link per open FD, only for the same user
+	//		status 		-> context switches
+	//
+
+	stats := contracts.ProcessStats{}
+
+	// 1 - read stat, `comm` is in parentheses and can have spaces
+	data, err := ioutil.ReadFile(path.Join(folder, "stat"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return contracts.ProcessStats{}, contracts.ErrProcessDoesNotExist
+		}
+		return contracts.ProcessStats{}, err
+	}
+
+	commEnd := strings.LastIndexByte(string(data), ')')
+	if commEnd < 0 {
+		return contracts.ProcessStats{}, fmt.Errorf("bad %s/stat", folder)
+	}
+
+	// from field 3 (state) on
+	fields := strings.Fields(string(data[commEnd+1:]))
+	if len(fields) < 18 {
+		return contracts.ProcessStats{}, fmt.Errorf("bad %s/stat", folder)
+	}
+
+	stats.CPUUser = clockTicks(fields[11])   // 14 - utime
+	stats.CPUSystem = clockTicks(fields[12]) // 15 - stime
+	stats.Threads, _ = strconv.Atoi(fields[17])
+
+	// 2 - read statm
+	data, err = ioutil.ReadFile(path.Join(folder, "statm"))
+	if err == nil {
+		pages := strings.Fields(string(data))
+		if len(pages) > 1 {
+			pageSize := uint64(os.Getpagesize())
+
+			size, _ := strconv.ParseUint(pages[0], 10, 64)
+			resident, _ := strconv.ParseUint(pages[1], 10, 64)
+
+			stats.MemoryVSZ = size * pageSize
+			stats.MemoryRSS = resident * pageSize
+		}
+	}
+
+	// 3 - read io
+	data, _ = ioutil.ReadFile(path.Join(folder, "io"))
+	for _, line := range strings.Split(string(data), "\n") {
+		props := strings.Split(line, ":")
+		if len(props) > 1 {
+			val, _ := strconv.ParseUint(strings.TrimSpace(props[1]), 10, 64)
+			switch strings.TrimSpace(props[0]) {
+			case "read_bytes":
+				stats.ReadBytes = val
+			case "write_bytes":
+				stats.WriteBytes = val
+			}
+		}
+	}
+
+	// 4 - read fd
+	if names, err := readDirNames(path.Join(folder, "fd")); err == nil {
+		stats.OpenFDs = len(names)
+	}
+
+	// 5 - read status
+	data, _ = ioutil.ReadFile(path.Join(folder, "status"))
+	for _, line := range strings.Split(string(data), "\n") {
+		props := strings.Split(line, ":")
+		if len(props) > 1 {
+			val, _ := strconv.ParseUint(strings.TrimSpace(props[1]), 10, 64)
+			switch strings.TrimSpace(props[0]) {
+			case "voluntary_ctxt_switches":
+				stats.VoluntaryContextSwitches = val
+			case "nonvoluntary_ctxt_switches":
+				stats.InvoluntaryContextSwitches = val
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+func clockTicks(field string) time.Duration {
+	ticks, _ := strconv.ParseUint(field, 10, 64)
+
+	return time.Duration(ticks) * time.Second / clockTicksPerSecond
+}
+
+func readDirNames(folder string) ([]string, error) {
+	d, err := os.Open(folder)
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	return d.Readdirnames(-1)
+}
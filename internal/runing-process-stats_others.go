@@ -0,0 +1,11 @@
+// +build !linux
+
+package internal
+
+import (
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+func readProcessStats(pid int) (contracts.ProcessStats, error) {
+	return contracts.ProcessStats{}, contracts.ErrStatsNotSupported
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// stdinWriter - serializes writes to STDIN, the sources from the template go first
+type stdinWriter struct {
+	pipe     io.WriteCloser
+	pipeSync *sync.Mutex
+	closed   bool
+}
+
+// notAvailableStdin - returned by `Stdin()` when the template did not ask for STDIN
+type notAvailableStdin struct{}
+
+func (thisRef notAvailableStdin) Write(p []byte) (int, error) {
+	return 0, contracts.ErrStdinNotAvailable
+}
+
+func (thisRef notAvailableStdin) Close() error {
+	return contracts.ErrStdinNotAvailable
+}
+
+// stdinFile - opened from `StdinFile`, closed once written, unlike an `*os.File` given as `StdinReader`
+type stdinFile struct {
+	*os.File
+}
+
+// newStdinWriter - starts writing `sources`, `Write()` waits until they are written
+func newStdinWriter(pipe io.WriteCloser, sources []io.Reader, closeWhenDone bool) *stdinWriter {
+	stdIn := &stdinWriter{
+		pipe:     pipe,
+		pipeSync: &sync.Mutex{},
+		closed:   false,
+	}
+
+	stdIn.pipeSync.Lock() // released by `feed()`
+	go stdIn.feed(sources, closeWhenDone)
+
+	return stdIn
+}
+
+func (thisRef *stdinWriter) Write(p []byte) (int, error) {
+	thisRef.pipeSync.Lock()
+	defer thisRef.pipeSync.Unlock()
+
+	if thisRef.closed {
+		return 0, os.ErrClosed
+	}
+
+	return thisRef.pipe.Write(p)
+}
+
+// Close - the process reads EOF
+func (thisRef *stdinWriter) Close() error {
+	thisRef.pipeSync.Lock()
+	defer thisRef.pipeSync.Unlock()
+
+	if thisRef.closed {
+		return nil
+	}
+	thisRef.closed = true
+
+	return thisRef.pipe.Close()
+}
+
+// feed - writes the sources to STDIN, called with `pipeSync` held
+func (thisRef *stdinWriter) feed(sources []io.Reader, closeWhenDone bool) {
+	for _, source := range sources {
+		if _, err := io.Copy(thisRef.pipe, source); err != nil {
+			logging.Warningf("%s: write-StdIn-FAIL, [%s]", logID, err.Error())
+			break
+		}
+	}
+
+	closeStdinSources(sources)
+
+	thisRef.pipeSync.Unlock()
+
+	if closeWhenDone {
+		thisRef.Close()
+	}
+}
+
+// stdinSources - what the template wants written to STDIN, files are opened here to fail the start early
+func stdinSources(processTemplate contracts.ProcessTemplate) ([]io.Reader, error) {
+	sources := []io.Reader{}
+
+	if len(processTemplate.StdinData) > 0 {
+		sources = append(sources, bytes.NewReader(processTemplate.StdinData))
+	}
+
+	if len(processTemplate.StdinFile) > 0 {
+		file, err := os.Open(processTemplate.StdinFile)
+		if err != nil {
+			return sources, err
+		}
+
+		sources = append(sources, stdinFile{file})
+	}
+
+	if processTemplate.StdinReader != nil {
+		sources = append(sources, processTemplate.StdinReader)
+	}
+
+	return sources, nil
+}
+
+// closeStdinSources - closes the files opened by `stdinSources()`, `StdinReader` belongs to the caller
+func closeStdinSources(sources []io.Reader) {
+	for _, source := range sources {
+		if file, ok := source.(stdinFile); ok {
+			file.Close()
+		}
+	}
+}
+
+// Stdin - writes to the STDIN of the current run, needs one of the STDIN fields in the template
+func (thisRef *runingProcess) Stdin() io.WriteCloser {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
+	if thisRef.stdIn == nil {
+		return notAvailableStdin{}
+	}
+
+	return thisRef.stdIn
+}
@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+func errUnknownSignal(signalName string) error {
+	return fmt.Errorf("unknown signal [%s]", signalName)
+}
+
+// Signal - sends the signal named `signalName`, like `SIGHUP`, to the whole group if the process leads one
+func (thisRef *runingProcess) Signal(signalName string) error {
+	osProc := thisRef.osProcess()
+	if osProc == nil || !thisRef.IsRunning() {
+		return contracts.ErrProcessDoesNotExist
+	}
+
+	return sendSignal(osProc, signalName, thisRef.signalsGroup())
+}
+
+// signalsGroup - the process leads its own process group, signals go to the whole group
+func (thisRef *runingProcess) signalsGroup() bool {
+	return thisRef.processTemplate.NewProcessGroup || thisRef.processTemplate.NewSession
+}
+
+// stopWithStrategy - follows the steps from the template, each step once
+func (thisRef *runingProcess) stopWithStrategy(osProc *os.Process, steps []contracts.StopStep, waitTimeout time.Duration) error {
+	pid := osProc.Pid
+
+	for i, step := range steps {
+		var err error
+		if len(step.Command) > 0 {
+			logging.Debugf("%s: stop-STEP #%d command %v to stop [%s]", logID, i, step.Command, thisRef.processTemplate.Executable)
+			err = runStopCommand(step.Command, pid)
+		} else {
+			logging.Debugf("%s: stop-STEP #%d signal %s to stop [%s]", logID, i, step.Signal, thisRef.processTemplate.Executable)
+			err = sendSignal(osProc, step.Signal, thisRef.signalsGroup())
+		}
+
+		if err != nil {
+			logging.Warningf("%s: stop-STEP-FAIL #%d for [%s], [%s]", logID, i, thisRef.processTemplate.Executable, err.Error())
+		}
+
+		gracePeriod := step.GracePeriod
+		if gracePeriod <= 0 {
+			gracePeriod = waitTimeout
+		}
+
+		if thisRef.waitExit(gracePeriod) {
+			logging.Debugf("%s: stop-SUCCESS [%s]", logID, thisRef.processTemplate.Executable)
+			return nil
+		}
+	}
+
+	logging.Errorf("%s: stop-FAIL [%s] with PID [%d], all %d steps done", logID, thisRef.processTemplate.Executable, pid, len(steps))
+
+	return contracts.ErrStopFailed
+}
+
+// runStopCommand - runs a custom stop command like `nginx -s quit`, waits for it to finish
+func runStopCommand(command []string, pid int) error {
+	pidAsString := strconv.Itoa(pid)
+
+	args := []string{}
+	for _, arg := range command[1:] {
+		args = append(args, strings.Replace(arg, "$MAINPID", pidAsString, -1))
+	}
+
+	stopCmd := exec.Command(command[0], args...)
+	stopCmd.Env = append(os.Environ(), "MAINPID="+pidAsString)
+
+	output, err := CombinedOutput(stopCmd)
+	if err != nil {
+		return fmt.Errorf("%s, output [%s]", err.Error(), strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"os"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// descendantsOf - PIDs of the children, grand children and so on of `pid`
+func descendantsOf(pid int) []int {
+	allProcesses, err := getAllRuningProcesses()
+	if err != nil {
+		logging.Warningf("%s: descendants-FAIL for [%d], [%s]", logID, pid, err.Error())
+		return []int{}
+	}
+
+	children := map[int][]int{}
+	for _, p := range allProcesses {
+		rp := p.Details()
+		if rp.ProcessID != rp.ParentProcessID {
+			children[rp.ParentProcessID] = append(children[rp.ParentProcessID], rp.ProcessID)
+		}
+	}
+
+	descendants := []int{}
+	toVisit := children[pid]
+	for len(toVisit) > 0 {
+		descendant := toVisit[0]
+		toVisit = toVisit[1:]
+
+		descendants = append(descendants, descendant)
+		toVisit = append(toVisit, children[descendant]...)
+	}
+
+	return descendants
+}
+
+// killProcesses - kills the ones still running
+func killProcesses(pids []int) {
+	for _, pid := range pids {
+		rp, err := getRuntimeProcessByPID(pid)
+		if err != nil ||
+			rp.State == contracts.ProcessStateNonExistent ||
+			rp.State == contracts.ProcessStateObsolete ||
+			rp.State == contracts.ProcessStateDead {
+			continue
+		}
+
+		osProc, err := os.FindProcess(pid)
+		if err != nil {
+			continue
+		}
+
+		logging.Debugf("%s: kill-TREE [%d] %s", logID, pid, rp.Executable)
+		osProc.Kill()
+	}
+}
@@ -2,10 +2,13 @@ package internal
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
@@ -24,8 +27,22 @@ type runingProcess struct {
 	osCmd           *exec.Cmd
 	startedAt       time.Time
 	stoppedAt       time.Time
-	stdOut          io.ReadCloser
-	stdErr          io.ReadCloser
+	output          *outputBuffer
+	subscribers     *outputHub
+	stdOut          *outputStream // `nil` until started
+	stdErr          *outputStream // `nil` until started
+	stdOutFile      contracts.OutputSink
+	stdErrFile      contracts.OutputSink
+	pumps           *sync.WaitGroup // STDOUT and STDERR still being read
+	stdIn           *stdinWriter    // `nil` unless the template asks for STDIN
+	pty             *pseudoTerminal // `nil` unless the template asks for a PTY
+	isOurChild      bool            // started by `Start`, waited for, `false` for adopted processes
+
+	lastStats contracts.ProcessStats // for the CPU percent of the next sample
+	statsSync *sync.Mutex
+
+	run     *processRun // `nil` until started
+	runSync *sync.Mutex
 }
 
 // NewEmptyRuningProcess -
@@ -40,6 +57,11 @@ func NewRuningProcess(processTemplate contracts.ProcessTemplate) contracts.Runin
 		osCmd:           nil,
 		startedAt:       time.Unix(0, 0),
 		stoppedAt:       time.Unix(0, 0),
+		output:          newOutputBuffer(processTemplate.OutputBuffer),
+		subscribers:     newOutputHub(),
+		pumps:           &sync.WaitGroup{},
+		statsSync:       &sync.Mutex{},
+		runSync:         &sync.Mutex{},
 	}
 }
 
@@ -50,8 +72,16 @@ func NewRuningProcessWithOSProc(processTemplate contracts.ProcessTemplate, osPro
 		osCmd:           exec.Command(processTemplate.Executable, processTemplate.Args...),
 		startedAt:       time.Unix(0, 0),
 		stoppedAt:       time.Unix(0, 0),
+		output:          newOutputBuffer(processTemplate.OutputBuffer),
+		subscribers:     newOutputHub(),
+		pumps:           &sync.WaitGroup{},
+		statsSync:       &sync.Mutex{},
+		run:             newProcessRun(),
+		runSync:         &sync.Mutex{},
 	}
 
+	// started elsewhere, even if it is our child its exit status belongs to whoever started it,
+	// the exit is only watched and reaping is left to the owner or to the orphan reaper
 	r.osCmd.Process = osProc
 
 	return r
@@ -59,58 +89,156 @@ func NewRuningProcessWithOSProc(processTemplate contracts.ProcessTemplate, osPro
 
 // Start -
 func (thisRef *runingProcess) Start() error {
-
-	thisRef.osCmd = exec.Command(thisRef.processTemplate.Executable, thisRef.processTemplate.Args...)
+	osCmd := exec.Command(thisRef.processTemplate.Executable, thisRef.processTemplate.Args...)
 
 	// set working folder
 	if !helpers.IsNullOrEmpty(thisRef.processTemplate.WorkingDirectory) {
-		thisRef.osCmd.Dir = thisRef.processTemplate.WorkingDirectory
+		osCmd.Dir = thisRef.processTemplate.WorkingDirectory
 	}
 
 	// set env
 	if thisRef.processTemplate.Environment != nil {
-		thisRef.osCmd.Env = thisRef.processTemplate.Environment
+		osCmd.Env = thisRef.processTemplate.Environment
 	}
 
-	// capture STDERR
-	stdOutPipe, err := thisRef.osCmd.StdoutPipe()
-	if err != nil {
-		logging.Errorf("%s: get-StdOut-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
+	osCmd.SysProcAttr = newProcAttrs(thisRef.processTemplate)
+
+	if err := setParentDeathSignal(osCmd, thisRef.processTemplate.ParentDeathSignal); err != nil {
+		logging.Errorf("%s: parent-death-signal-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
 		return err
 	}
-	thisRef.stdOut = stdOutPipe
 
-	// capture STDERR
-	stdErrPipe, err := thisRef.osCmd.StderrPipe()
+	// run as another user and group
+	credentials, err := resolveCredentials(thisRef.processTemplate)
+	if err == nil && credentials != nil && credentials.switchUser {
+		err = setCredentials(osCmd, credentials)
+	}
 	if err != nil {
-		logging.Errorf("%s: get-StdErr-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
+		logging.Errorf("%s: credentials-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
+		return err
+	}
+	if credentials != nil && thisRef.processTemplate.UserEnvironment {
+		osCmd.Env = withUserEnvironment(osCmd.Env, credentials.user)
+	}
+
+	// sandbox in new namespaces
+	namespaces := thisRef.processTemplate.Namespaces
+	if !namespaces.IsEmpty() {
+		if err := setNamespaces(osCmd, namespaces); err != nil {
+			logging.Errorf("%s: namespaces-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
+			return err
+		}
+	}
+
+	if err := thisRef.openOutputFiles(); err != nil {
+		logging.Errorf("%s: open-output-files-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
 		return err
 	}
-	thisRef.stdErr = stdErrPipe
 
-	thisRef.osCmd.SysProcAttr = procAttrs
+	// cgroup, the process is cloned into it
+	var group *runCgroup
+	if config := thisRef.processTemplate.Cgroup; !config.IsEmpty() {
+		var err error
+		group, err = createCgroup(config, fmt.Sprintf("%s-%d", filepath.Base(thisRef.processTemplate.Executable), time.Now().UnixNano()))
+		if err != nil {
+			logging.Errorf("%s: cgroup-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
+			return err
+		}
+		group.attach(osCmd)
+	}
+
+	// capture STDOUT and STDERR, feed STDIN
+	pIO, err := newProcessIO(osCmd, thisRef.processTemplate)
+	if err != nil {
+		if group != nil {
+			group.remove()
+		}
+
+		logging.Errorf("%s: get-StdIO-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
+		return err
+	}
 
 	// start
 	logging.Debugf("%s: start %s", logID, helpers.AsJSONString(thisRef.processTemplate))
 
-	err = thisRef.osCmd.Start()
+	run := newProcessRun()
+	run.cgroup = group
+
+	err = startChild(osCmd, func() error {
+		return startProcess(osCmd, namespaces, thisRef.processTemplate.ResourceLimits, run.exited)
+	})
+
+	// the kernel only tells EPERM
+	if err != nil && credentials != nil && credentials.switchUser && errors.Is(err, syscall.EPERM) {
+		err = fmt.Errorf("%w: uid %d, gid %d, %s", contracts.ErrCredentialsNotPermitted, credentials.uid, credentials.gid, err.Error())
+	} else if err != nil && !namespaces.IsEmpty() && errors.Is(err, syscall.EPERM) {
+		err = fmt.Errorf("%w: %s", contracts.ErrNamespacesNotPermitted, err.Error())
+	}
+
+	if group != nil {
+		if err == nil {
+			if groupErr := group.started(osCmd.Process.Pid); groupErr != nil {
+				osCmd.Process.Kill()
+				osCmd.Wait()
+				untrackChild(osCmd.Process.Pid)
+				err = fmt.Errorf("cgroup-FAIL, %s", groupErr.Error())
+			}
+		}
+
+		if err != nil {
+			group.remove()
+		}
+	}
+
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
+	thisRef.osCmd = osCmd
+
 	if err != nil {
 		thisRef.stoppedAt = time.Now()
+		pIO.startFailed()
+		close(run.exited) // the run is never watched, release the start thread of a hostname
 
-		detailedErr := fmt.Errorf("%s: start-FAILED %s, %s", logID, helpers.AsJSONString(thisRef.processTemplate), err.Error())
+		detailedErr := fmt.Errorf("%s: start-FAILED %s, %w", logID, helpers.AsJSONString(thisRef.processTemplate), err)
 		logging.Error(detailedErr.Error())
 
 		return detailedErr
 	}
 
+	pIO.started()
+	thisRef.stdOut = newOutputStream(contracts.OutputStreamStdOut, thisRef.output, thisRef.subscribers)
+	thisRef.stdErr = newOutputStream(contracts.OutputStreamStdErr, thisRef.output, thisRef.subscribers)
+	if thisRef.stdOutFile != nil {
+		thisRef.stdOut.addReader(thisRef.stdOutFile.OnOutput, nil)
+	}
+	if thisRef.stdErrFile != nil && pIO.stdErr != nil {
+		thisRef.stdErr.addReader(thisRef.stdErrFile.OnOutput, nil)
+	}
+	if stdOutParser, stdErrParser := thisRef.logParsers(); stdOutParser != nil {
+		thisRef.stdOut.addReader(stdOutParser, nil)
+		thisRef.stdErr.addReader(stdErrParser, nil)
+	}
+	thisRef.pumps.Add(1)
+	go thisRef.stdOut.pump(pIO.stdOut, thisRef.processTemplate, thisRef.pumps)
+	if pIO.stdErr != nil {
+		thisRef.pumps.Add(1)
+		go thisRef.stdErr.pump(pIO.stdErr, thisRef.processTemplate, thisRef.pumps)
+	}
+	thisRef.stdIn = pIO.stdinWriter()
+	thisRef.pty = pIO.pty
 	thisRef.startedAt = time.Now()
+	thisRef.isOurChild = true
+	thisRef.run = run
+	thisRef.watchRun(thisRef.run)
 
 	return nil
 }
 
 // Stop - stops the process
 func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Duration) error {
-	if thisRef.osCmd == nil || thisRef.osCmd.Process == nil {
+	osProc := thisRef.osProcess()
+	if osProc == nil {
 		return nil
 	}
 
@@ -118,6 +246,27 @@ func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Du
 		return nil
 	}
 
+	// the cgroup is removed once the exit is recorded, return after that
+	if run := thisRef.currentRun(); run != nil && run.cgroup != nil {
+		defer func() {
+			select {
+			case <-run.exited:
+			case <-time.After(5 * time.Second):
+			}
+		}()
+	}
+
+	// whatever is left in the group got the same signals as the leader, finish it once the leader is gone
+	if thisRef.signalsGroup() {
+		defer signalProcess(osProc, syscall.SIGKILL, true)
+	}
+
+	// collect before stopping, once the process exits its orphans are reparented and can't be found
+	if thisRef.processTemplate.KillTree {
+		descendants := descendantsOf(osProc.Pid)
+		defer killProcesses(descendants)
+	}
+
 	// go func() {
 	// 	if thisRef.stdOut != nil {
 	// 		thisRef.stdOut.Close()
@@ -134,6 +283,10 @@ func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Du
 
 	logging.Debugf("%s: STOP-START %s", logID, tag)
 
+	if steps := thisRef.processTemplate.StopStrategy.Steps; len(steps) > 0 {
+		return thisRef.stopWithStrategy(osProc, steps, waitTimeout)
+	}
+
 	var err error
 	count := 0
 	maxStopAttempts := 20
@@ -149,13 +302,10 @@ func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Du
 
 		for i := 0; i < attempts; i++ {
 			logging.Debugf("%s: stop-ATTEMPT-SIGINT #%d to stop [%s]", logID, i, thisRef.processTemplate.Executable)
-			thisRef.osCmd.Process.Signal(syscall.SIGINT) // this works on all except on Windows
-			sendCtrlC(thisRef.osCmd.Process.Pid)         // this works on Windows
+			signalProcess(osProc, syscall.SIGINT, thisRef.signalsGroup()) // this works on all except on Windows
+			sendCtrlC(osProc.Pid)                                         // this works on Windows
 
-			time.Sleep(waitTimeout)
-			if !thisRef.IsRunning() {
-				thisRef.osCmd.Process.Wait()
-				thisRef.stoppedAt = time.Now()
+			if thisRef.waitExit(waitTimeout) {
 				logging.Debugf("%s: stop-SUCCESS [%s]", logID, thisRef.processTemplate.Executable)
 				return nil
 			}
@@ -163,11 +313,8 @@ func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Du
 
 		for i := 0; i < attempts; i++ {
 			logging.Debugf("%s: stop-ATTEMPT-SIGTERM #%d to stop [%s]", logID, i, thisRef.processTemplate.Executable)
-			thisRef.osCmd.Process.Signal(syscall.SIGTERM)
-			time.Sleep(waitTimeout)
-			if !thisRef.IsRunning() {
-				thisRef.osCmd.Process.Wait()
-				thisRef.stoppedAt = time.Now()
+			signalProcess(osProc, syscall.SIGTERM, thisRef.signalsGroup())
+			if thisRef.waitExit(waitTimeout) {
 				logging.Debugf("%s: stop-SUCCESS [%s]", logID, thisRef.processTemplate.Executable)
 				return nil
 			}
@@ -175,11 +322,8 @@ func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Du
 
 		for i := 0; i < attempts; i++ {
 			logging.Debugf("%s: stop-ATTEMPT-SIGKILL #%d to stop [%s]", logID, i, thisRef.processTemplate.Executable)
-			thisRef.osCmd.Process.Signal(syscall.SIGKILL)
-			time.Sleep(waitTimeout)
-			if !thisRef.IsRunning() {
-				thisRef.osCmd.Process.Wait()
-				thisRef.stoppedAt = time.Now()
+			signalProcess(osProc, syscall.SIGKILL, thisRef.signalsGroup())
+			if thisRef.waitExit(waitTimeout) {
 				logging.Debugf("%s: stop-SUCCESS [%s]", logID, thisRef.processTemplate.Executable)
 				return nil
 			}
@@ -187,11 +331,8 @@ func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Du
 
 		for i := 0; i < attempts; i++ {
 			logging.Debugf("%s: stop-ATTEMPT-aggressive-kill-1 #%d to stop [%s]", logID, i, thisRef.processTemplate.Executable)
-			processKillHelper(thisRef.osCmd.Process.Pid)
-			time.Sleep(waitTimeout)
-			if !thisRef.IsRunning() {
-				thisRef.osCmd.Process.Wait()
-				thisRef.stoppedAt = time.Now()
+			processKillHelper(osProc.Pid)
+			if thisRef.waitExit(waitTimeout) {
 				logging.Debugf("%s: stop-SUCCESS [%s]", logID, thisRef.processTemplate.Executable)
 				return nil
 			}
@@ -199,11 +340,8 @@ func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Du
 
 		for i := 0; i < attempts; i++ {
 			logging.Debugf("%s: stop-ATTEMPT-aggressive-kill-2 #%d to stop [%s]", logID, i, thisRef.processTemplate.Executable)
-			err = thisRef.osCmd.Process.Kill()
-			time.Sleep(waitTimeout)
-			if !thisRef.IsRunning() {
-				thisRef.osCmd.Process.Wait()
-				thisRef.stoppedAt = time.Now()
+			err = osProc.Kill()
+			if thisRef.waitExit(waitTimeout) {
 				logging.Debugf("%s: stop-SUCCESS [%s]", logID, thisRef.processTemplate.Executable)
 				return nil
 			}
@@ -214,12 +352,20 @@ func (thisRef *runingProcess) Stop(tag string, attempts int, waitTimeout time.Du
 }
 
 // IsRunning - tells if the process is running
-func (thisRef runingProcess) IsRunning() bool {
+func (thisRef *runingProcess) IsRunning() bool {
 	pid := thisRef.processID()
 	if pid == processDoesNotExist {
 		return false
 	}
 
+	thisRef.runSync.Lock()
+	runDone := (thisRef.run != nil && thisRef.run.done)
+	thisRef.runSync.Unlock()
+
+	if runDone {
+		return false
+	}
+
 	rp := thisRef.Details()
 
 	return (rp.State != contracts.ProcessStateNonExistent &&
@@ -229,28 +375,44 @@ func (thisRef runingProcess) IsRunning() bool {
 }
 
 // Details - return processTemplate about the process
-func (thisRef runingProcess) Details() contracts.RuntimeProcess {
+func (thisRef *runingProcess) Details() contracts.RuntimeProcess {
 	rpByPID, err := getRuntimeProcessByPID(thisRef.processID())
 	if err != nil {
-		return contracts.RuntimeProcess{
+		rp := contracts.RuntimeProcess{
 			State: contracts.ProcessStateNonExistent,
 		}
+
+		// keep the PID of the last run, useful to tell what exited
+		if pid := thisRef.processID(); pid != processDoesNotExist {
+			rp.ProcessID = pid
+		}
+
+		return rp
 	}
 
+	// only here, enumerating and liveness checks don't need them
+	rpByPID.Limits = readResourceLimits(rpByPID.ProcessID)
+
 	return rpByPID
 }
 
-// ExitCode -
-func (thisRef runingProcess) ExitCode() int {
-	if thisRef.osCmd == nil || thisRef.osCmd.Process == nil || thisRef.osCmd.ProcessState == nil {
+// ExitCode - exit code of the last run, 0 while running
+func (thisRef *runingProcess) ExitCode() int {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
+	if thisRef.run == nil || !thisRef.run.done {
 		return 0
 	}
 
-	return thisRef.osCmd.ProcessState.ExitCode()
+	return thisRef.run.exitStatus.Code
 }
 
 // StartedAt - returns the time when the process was started
-func (thisRef runingProcess) StartedAt() time.Time {
+func (thisRef *runingProcess) StartedAt() time.Time {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
 	if thisRef.osCmd == nil || thisRef.osCmd.Process == nil {
 		return time.Unix(0, 0)
 	}
@@ -259,7 +421,10 @@ func (thisRef runingProcess) StartedAt() time.Time {
 }
 
 // StoppedAt - returns the time when the process was stopped
-func (thisRef runingProcess) StoppedAt() time.Time {
+func (thisRef *runingProcess) StoppedAt() time.Time {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
 	if thisRef.osCmd == nil || thisRef.osCmd.Process == nil {
 		return time.Unix(0, 0)
 	}
@@ -267,76 +432,158 @@ func (thisRef runingProcess) StoppedAt() time.Time {
 	return thisRef.stoppedAt
 }
 
-func (thisRef runingProcess) OnStdOut(outputReader contracts.ProcessOutputReader, params interface{}) {
+// OnStdOut - `outputReader` gets the lines of the current run, including the ones written before the call
+func (thisRef *runingProcess) OnStdOut(outputReader contracts.ProcessOutputReader, params interface{}) {
 	logging.Debugf("%s: read-StdOut for [%s]", logID, thisRef.processTemplate.Executable)
 
 	if outputReader != nil {
-		go func() {
-			err := readOutput(thisRef.stdOut, outputReader, params)
-			if err != nil {
-				logging.Warningf("%s: read-StdOut-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
-			}
+		thisRef.runSync.Lock()
+		stdOut := thisRef.stdOut
+		thisRef.runSync.Unlock()
 
-			logging.Debugf("%s: read-StdOut-SUCESS for [%s]", logID, thisRef.processTemplate.Executable)
-		}()
+		if stdOut == nil {
+			logging.Warningf("%s: read-StdOut-FAIL for [%s], not started", logID, thisRef.processTemplate.Executable)
+			return
+		}
+
+		stdOut.addReader(outputReader, params)
 	}
 }
 
-func (thisRef runingProcess) OnStdErr(outputReader contracts.ProcessOutputReader, params interface{}) {
+// OnStdErr - `outputReader` gets the lines of the current run, including the ones written before the call
+func (thisRef *runingProcess) OnStdErr(outputReader contracts.ProcessOutputReader, params interface{}) {
 	logging.Debugf("%s: read-StdErr for [%s]", logID, thisRef.processTemplate.Executable)
 
 	if outputReader != nil {
-		go func() {
-			err := readOutput(thisRef.stdErr, outputReader, params)
-			if err != nil {
-				logging.Warningf("%s: read-StdErr-FAIL for [%s], [%s]", logID, thisRef.processTemplate.Executable, err.Error())
-			}
+		thisRef.runSync.Lock()
+		stdErr := thisRef.stdErr
+		pty := thisRef.pty
+		thisRef.runSync.Unlock()
+
+		if stdErr == nil {
+			logging.Warningf("%s: read-StdErr-FAIL for [%s], not started", logID, thisRef.processTemplate.Executable)
+			return
+		}
 
-			logging.Debugf("%s: read-StdErr-SUCESS for [%s]", logID, thisRef.processTemplate.Executable)
-		}()
+		if pty != nil {
+			logging.Debugf("%s: read-StdErr-SKIP for [%s], no STDERR in PTY mode", logID, thisRef.processTemplate.Executable)
+			return
+		}
+
+		stdErr.addReader(outputReader, params)
 	}
 }
 
+// OnStop - `stoppedDelegate` is called exactly once, when the current run exits
 func (thisRef *runingProcess) OnStop(stoppedDelegate contracts.ProcessStoppedDelegate, params interface{}) {
-	go func(paramsToPass interface{}) {
-		for {
-			time.Sleep(1 * time.Second)
+	if stoppedDelegate == nil {
+		return
+	}
 
-			if !thisRef.IsRunning() {
-				thisRef.Stop("", 1, 100*time.Millisecond) // call this because .osCmd.Process.Wait() is needed
-				if stoppedDelegate != nil {
-					stoppedDelegate(paramsToPass)
-				}
+	thisRef.runSync.Lock()
 
-				return
-			}
+	run := thisRef.run
+	if run == nil || run.done {
+		exitStatus := unknownExitStatus()
+		if run != nil {
+			exitStatus = run.exitStatus
 		}
-	}(params)
+		thisRef.runSync.Unlock()
+
+		stoppedDelegate(params, exitStatus)
+		return
+	}
+
+	run.delegates = append(run.delegates, exitDelegate{
+		delegate: stoppedDelegate,
+		params:   params,
+	})
+	thisRef.watchRun(run)
+
+	thisRef.runSync.Unlock()
 }
 
-func (thisRef runingProcess) processID() int {
-	if thisRef.osCmd == nil || thisRef.osCmd.Process == nil {
+func (thisRef *runingProcess) processID() int {
+	osProc := thisRef.osProcess()
+	if osProc == nil {
 		return processDoesNotExist
 	}
 
-	return thisRef.osCmd.Process.Pid
+	return osProc.Pid
 }
 
-func readOutput(readerCloser io.ReadCloser, outputReader contracts.ProcessOutputReader, params interface{}) error {
+// osProcess - the OS process of the current run, `nil` if never started
+func (thisRef *runingProcess) osProcess() *os.Process {
+	thisRef.runSync.Lock()
+	defer thisRef.runSync.Unlock()
+
+	if thisRef.osCmd == nil {
+		return nil
+	}
+
+	return thisRef.osCmd.Process
+}
+
+// readLines - calls `onLine` with every line without its line break, the part of a line past `maxLineLength` is dropped
+func readLines(readerCloser io.Reader, maxLineLength int, onLine func(line []byte, truncated bool)) error {
 	reader := bufio.NewReader(readerCloser)
-	line, _, err := reader.ReadLine()
+	line := []byte{}
+	truncated := false
+
 	for {
-		if err != nil {
-			break
+		fragment, err := reader.ReadSlice('\n')
+
+		complete := (err == nil)
+		if complete {
+			fragment = fragment[:len(fragment)-1]
 		}
 
-		outputReader(params, line)
-		line, _, err = reader.ReadLine()
-	}
+		if room := maxLineLength - len(line); len(fragment) > room {
+			fragment = fragment[:room]
+			truncated = true
+		}
+		line = append(line, fragment...)
 
-	if err == io.EOF {
-		return nil
+		// the last line may have no line break
+		if complete || (err != bufio.ErrBufferFull && (len(line) > 0 || truncated)) {
+			if complete && !truncated && len(line) > 0 && line[len(line)-1] == '\r' {
+				line = line[:len(line)-1]
+			}
+
+			onLine(line, truncated)
+
+			line = line[:0]
+			truncated = false
+		}
+
+		if err == nil || err == bufio.ErrBufferFull {
+			continue
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		return err
 	}
+}
 
-	return err
+// readChunks - calls `onChunk` with the bytes exactly as they were read
+func readChunks(reader io.Reader, onChunk func(chunk []byte)) error {
+	chunk := make([]byte, 32*1024)
+
+	for {
+		n, err := reader.Read(chunk)
+		if n > 0 {
+			onChunk(chunk[:n])
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
 }
@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+const defaultBackoffMultiplier = 2
+
+// backoffDelay - delay before restart #`attempt` (0 based), jitter included
+func backoffDelay(backoff contracts.BackoffPolicy, attempt int) time.Duration {
+	if backoff.InitialDelay <= 0 {
+		return 0
+	}
+
+	multiplier := backoff.Multiplier
+	if multiplier <= 0 {
+		multiplier = defaultBackoffMultiplier
+	}
+
+	delay := float64(backoff.InitialDelay) * math.Pow(multiplier, float64(attempt))
+	if backoff.MaxDelay > 0 && delay > float64(backoff.MaxDelay) {
+		delay = float64(backoff.MaxDelay)
+	}
+
+	if backoff.Jitter > 0 {
+		jitter := math.Min(backoff.Jitter, 1)
+		delay = delay + delay*jitter*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(delay)
+}
+
+// scheduleRestart - picks the delay for the next restart, the run lasted `ranFor`
+func (thisRef *restartState) scheduleRestart(ranFor time.Duration, now time.Time) time.Duration {
+	backoff := thisRef.policy.Backoff
+	if backoff.ResetAfter > 0 && ranFor >= backoff.ResetAfter {
+		thisRef.backoffAttempt = 0
+	}
+
+	thisRef.currentDelay = backoffDelay(backoff, thisRef.backoffAttempt)
+	thisRef.nextRestartAt = now.Add(thisRef.currentDelay)
+	thisRef.backoffAttempt++
+
+	return thisRef.currentDelay
+}
+
+// cancelPendingRestart - drops a scheduled restart, if any
+func (thisRef *restartState) cancelPendingRestart() {
+	if thisRef.pendingRestart != nil {
+		thisRef.pendingRestart.Stop()
+		thisRef.pendingRestart = nil
+	}
+}
+
+// asContract - snapshot exposed through `Monitor.GetRestartState`
+func (thisRef *restartState) asContract() contracts.RestartState {
+	return contracts.RestartState{
+		Restarts:       len(thisRef.restarts),
+		BackoffAttempt: thisRef.backoffAttempt,
+		CurrentDelay:   thisRef.currentDelay,
+		Pending:        thisRef.pendingRestart != nil,
+		NextRestartAt:  thisRef.nextRestartAt,
+		GaveUp:         thisRef.gaveUp,
+	}
+}
@@ -0,0 +1,310 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// SpawnAll - monitors all, then starts them in dependency order, nothing is spawned if the dependencies are broken
+// or a health check is invalid
+func (thisRef *processMonitor) SpawnAll(processes map[string]contracts.ProcessTemplate) error {
+	tags := []string{}
+	for tag := range processes {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	thisRef.procsSync.Lock()
+	known := map[string]contracts.ProcessTemplate{}
+	for tag, template := range thisRef.templates {
+		known[tag] = template
+	}
+	thisRef.procsSync.Unlock()
+
+	for tag, template := range processes {
+		known[tag] = template
+	}
+
+	levels, err := startLevels(known, tags)
+	if err == nil {
+		err = validateAllHealthChecks(processes, tags)
+	}
+	if err != nil {
+		logging.Errorf("%s: spawn-all-FAIL, %s", logID, err.Error())
+		return err
+	}
+
+	for _, tag := range tags {
+		thisRef.add(processes[tag], tag)
+	}
+
+	_, err = thisRef.startInOrder(levels)
+
+	return err
+}
+
+// StartAll - starts every monitored process that is not running, in dependency order
+func (thisRef *processMonitor) StartAll() error {
+	thisRef.procsSync.Lock()
+	known := map[string]contracts.ProcessTemplate{}
+	for tag, template := range thisRef.templates {
+		known[tag] = template
+	}
+	thisRef.procsSync.Unlock()
+
+	tags := []string{}
+	for tag := range known {
+		if !thisRef.GetProcess(tag).IsRunning() {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	levels, err := startLevels(known, tags)
+	if err != nil {
+		logging.Errorf("%s: start-all-FAIL, %s", logID, err.Error())
+		return err
+	}
+
+	_, err = thisRef.startInOrder(levels)
+
+	return err
+}
+
+// StopAll - stops dependents before what they depend on, independent processes in parallel, waits for all
+func (thisRef *processMonitor) StopAll() {
+	thisRef.procsSync.Lock()
+	known := map[string]contracts.ProcessTemplate{}
+	tags := []string{}
+	for tag, template := range thisRef.templates {
+		known[tag] = template
+		tags = append(tags, tag)
+	}
+	thisRef.procsSync.Unlock()
+
+	sort.Strings(tags)
+
+	levels, err := startLevels(known, tags)
+	if err != nil {
+		// no order to follow, stop everything at once
+		logging.Warningf("%s: stop-all-UNORDERED, %s", logID, err.Error())
+		levels = [][]string{tags}
+	}
+
+	thisRef.stopInReverseOrder(levels)
+}
+
+// stopInReverseOrder - the last level first, the tags of a level in parallel, returns the error of each tag,
+// not a stop of the user, `RestartUnlessStopped` tags come back with `StartAll`
+func (thisRef *processMonitor) stopInReverseOrder(levels [][]string) map[string]error {
+	errs := map[string]error{}
+	errsSync := &sync.Mutex{}
+
+	for i := len(levels) - 1; i >= 0; i-- {
+		wg := sync.WaitGroup{}
+		for _, tag := range levels[i] {
+			wg.Add(1)
+			go func(tag string) {
+				defer wg.Done()
+				if err := thisRef.stop(tag, 3, 0*time.Millisecond, false); err != nil {
+					errsSync.Lock()
+					errs[tag] = err
+					errsSync.Unlock()
+				}
+			}(tag)
+		}
+		wg.Wait()
+	}
+
+	return errs
+}
+
+// startInOrder - a process whose requirements did not start or get ready is not started,
+// returns the error of each tag and the first one
+func (thisRef *processMonitor) startInOrder(levels [][]string) (map[string]error, error) {
+	errs := map[string]error{}
+	failed := map[string]bool{}
+	var firstErr error
+
+	for _, level := range levels {
+		for _, tag := range level {
+			thisRef.procsSync.Lock()
+			requires := thisRef.templates[tag].Requires
+			thisRef.procsSync.Unlock()
+
+			err := thisRef.waitForRequirements(tag, requires, failed)
+			if err != nil {
+				logging.Errorf("%s: start-SKIP %s, %s", logID, tag, err.Error())
+			} else {
+				err = thisRef.startUnlessStopped(tag)
+			}
+
+			if err != nil {
+				errs[tag] = err
+				failed[tag] = true
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}
+	}
+
+	return errs, firstErr
+}
+
+// waitForRequirements - fails for the first requirement that failed, is not running, or did not get ready in its `StartTimeout`
+func (thisRef *processMonitor) waitForRequirements(tag string, requires []string, failed map[string]bool) error {
+	for _, requirement := range requires {
+		if failed[requirement] || !thisRef.GetProcess(requirement).IsRunning() {
+			return fmt.Errorf("%w: %s requires %s", contracts.ErrDependencyFailed, tag, requirement)
+		}
+
+		thisRef.procsSync.Lock()
+		readiness := thisRef.templates[requirement].Readiness
+		thisRef.procsSync.Unlock()
+
+		startTimeout := readiness.StartTimeout
+		if startTimeout <= 0 {
+			startTimeout = defaultStartTimeout
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), startTimeout)
+		err := thisRef.WaitReady(ctx, requirement)
+		cancel()
+
+		if err != nil {
+			return fmt.Errorf("%w: %s requires %s, %s", contracts.ErrDependencyFailed, tag, requirement, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// hasDependencies - call with `procsSync` held
+func (thisRef *processMonitor) hasDependencies() bool {
+	for _, template := range thisRef.templates {
+		if len(template.Requires) > 0 || len(template.After) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startLevels - `tags` grouped so that each group depends only on the groups before it
+//
+// `known` are all the templates, dependencies outside `tags` are not ordered but `Requires` must be known
+func startLevels(known map[string]contracts.ProcessTemplate, tags []string) ([][]string, error) {
+	inSet := map[string]bool{}
+	for _, tag := range tags {
+		inSet[tag] = true
+	}
+
+	dependencies := map[string][]string{}
+	for _, tag := range tags {
+		template := known[tag]
+
+		for _, requirement := range template.Requires {
+			if _, ok := known[requirement]; !ok {
+				return nil, fmt.Errorf("%w: %s requires %s", contracts.ErrDependencyMissing, tag, requirement)
+			}
+		}
+
+		for _, dependency := range append(append([]string{}, template.Requires...), template.After...) {
+			if inSet[dependency] && dependency != tag {
+				dependencies[tag] = append(dependencies[tag], dependency)
+			} else if dependency == tag {
+				return nil, fmt.Errorf("%w: %s -> %s", contracts.ErrDependencyCycle, tag, tag)
+			}
+		}
+	}
+
+	if cycle := findCycle(tags, dependencies); len(cycle) > 0 {
+		return nil, fmt.Errorf("%w: %s", contracts.ErrDependencyCycle, strings.Join(cycle, " -> "))
+	}
+
+	levels := [][]string{}
+	started := map[string]bool{}
+	for len(started) < len(tags) {
+		level := []string{}
+		for _, tag := range tags {
+			if started[tag] {
+				continue
+			}
+
+			ready := true
+			for _, dependency := range dependencies[tag] {
+				if !started[dependency] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				level = append(level, tag)
+			}
+		}
+
+		for _, tag := range level {
+			started[tag] = true
+		}
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+// findCycle - depth first, returns the tags of the first cycle found, closed on the first one
+func findCycle(tags []string, dependencies map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[string]int{}
+	path := []string{}
+
+	var visit func(tag string) []string
+	visit = func(tag string) []string {
+		state[tag] = visiting
+		path = append(path, tag)
+
+		for _, dependency := range dependencies[tag] {
+			switch state[dependency] {
+			case visiting:
+				for i, t := range path {
+					if t == dependency {
+						return append(append([]string{}, path[i:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); len(cycle) > 0 {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[tag] = visited
+
+		return nil
+	}
+
+	for _, tag := range tags {
+		if state[tag] == unvisited {
+			if cycle := visit(tag); len(cycle) > 0 {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// eventsBufferSize - events a subscriber can fall behind before new ones are dropped for it
+const eventsBufferSize = 64
+
+// eventHub - non-blocking fan-out of monitor events to any number of subscribers
+type eventHub struct {
+	subscribers     map[int]chan contracts.MonitorEvent
+	subscribersSync *sync.Mutex
+	nextID          int
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		subscribers:     map[int]chan contracts.MonitorEvent{},
+		subscribersSync: &sync.Mutex{},
+		nextID:          0,
+	}
+}
+
+func (thisRef *eventHub) subscribe() (<-chan contracts.MonitorEvent, func()) {
+	thisRef.subscribersSync.Lock()
+	defer thisRef.subscribersSync.Unlock()
+
+	id := thisRef.nextID
+	thisRef.nextID++
+
+	events := make(chan contracts.MonitorEvent, eventsBufferSize)
+	thisRef.subscribers[id] = events
+
+	unsubscribe := func() {
+		thisRef.subscribersSync.Lock()
+		defer thisRef.subscribersSync.Unlock()
+
+		if _, ok := thisRef.subscribers[id]; ok {
+			delete(thisRef.subscribers, id)
+			close(events)
+		}
+	}
+
+	return events, unsubscribe
+}
+
+// publish - never blocks, a subscriber with a full buffer misses the event
+func (thisRef *eventHub) publish(event contracts.MonitorEvent) {
+	thisRef.subscribersSync.Lock()
+	defer thisRef.subscribersSync.Unlock()
+
+	for id, events := range thisRef.subscribers {
+		select {
+		case events <- event:
+		default:
+			logging.Warningf("%s: event-DROP %s for %s, subscriber %d is full", logID, event.Type, event.Tag, id)
+		}
+	}
+}
+
+// newMonitorEvent - `rp` can be `nil` for processes no longer monitored
+func newMonitorEvent(eventType contracts.MonitorEventType, tag string, rp contracts.RuningProcess) contracts.MonitorEvent {
+	event := contracts.MonitorEvent{
+		Type: eventType,
+		Tag:  tag,
+		Time: time.Now(),
+	}
+
+	if rp != nil {
+		event.Process = rp.Details()
+		event.ProcessID = event.Process.ProcessID
+		event.StartedAt = rp.StartedAt()
+		event.StoppedAt = rp.StoppedAt()
+	}
+
+	return event
+}
+
+// Events - subscribes to the monitor events, call the returned func to unsubscribe
+func (thisRef *processMonitor) Events() (<-chan contracts.MonitorEvent, func()) {
+	return thisRef.events.subscribe()
+}
+
+func (thisRef *processMonitor) publishEvent(eventType contracts.MonitorEventType, tag string, rp contracts.RuningProcess) {
+	thisRef.events.publish(newMonitorEvent(eventType, tag, rp))
+}
@@ -0,0 +1,161 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"github.com/codemodify/systemkit-processes/internal"
+)
+
+// healthHTTPClient - redirects are not followed, a 3xx passes like in Kubernetes
+var healthHTTPClient = &http.Client{
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// healthProbe - one check of one run, with the defaults applied
+type healthProbe struct {
+	check     contracts.HealthCheck
+	pattern   *regexp.Regexp
+	matched   bool // output, a line matched since the previous check
+	matchSync *sync.Mutex
+}
+
+func newHealthProbe(check contracts.HealthCheck) (*healthProbe, error) {
+	if check.Interval <= 0 {
+		check.Interval = 10 * time.Second
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = time.Second
+	}
+	if check.SuccessThreshold <= 0 {
+		check.SuccessThreshold = 1
+	}
+	if check.FailureThreshold <= 0 {
+		check.FailureThreshold = 3
+	}
+
+	probe := &healthProbe{
+		check:     check,
+		matchSync: &sync.Mutex{},
+	}
+
+	if check.Type == contracts.HealthCheckOutput {
+		pattern, err := regexp.Compile(check.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		probe.pattern = pattern
+	}
+
+	return probe, nil
+}
+
+// onOutput - a `contracts.ProcessOutputReader` for the output check
+func (thisRef *healthProbe) onOutput(params interface{}, outputData []byte) {
+	if !thisRef.pattern.Match(outputData) {
+		return
+	}
+
+	thisRef.matchSync.Lock()
+	thisRef.matched = true
+	thisRef.matchSync.Unlock()
+}
+
+// run - `nil` if the check passes
+func (thisRef *healthProbe) run(ctx context.Context, processID int) error {
+	ctx, cancel := context.WithTimeout(ctx, thisRef.check.Timeout)
+	defer cancel()
+
+	switch thisRef.check.Type {
+	case contracts.HealthCheckExec:
+		return probeExec(ctx, thisRef.check.Command, processID)
+	case contracts.HealthCheckTCP:
+		return probeTCP(ctx, thisRef.check.Address)
+	case contracts.HealthCheckHTTP:
+		return probeHTTP(ctx, thisRef.check.URL)
+	case contracts.HealthCheckOutput:
+		thisRef.matchSync.Lock()
+		defer thisRef.matchSync.Unlock()
+
+		matched := thisRef.matched
+		thisRef.matched = false
+
+		if !matched {
+			return fmt.Errorf("no line matched [%s]", thisRef.check.Pattern)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown health check [%s]", thisRef.check.Type)
+	}
+}
+
+func probeExec(ctx context.Context, command []string, processID int) error {
+	if len(command) == 0 {
+		return fmt.Errorf("no command")
+	}
+
+	mainPID := strconv.Itoa(processID)
+
+	args := make([]string, 0, len(command))
+	for _, arg := range command {
+		args = append(args, strings.ReplaceAll(arg, "$MAINPID", mainPID))
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "MAINPID="+mainPID)
+
+	output, err := internal.CombinedOutput(cmd)
+	if err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("%s, %s", err.Error(), strings.TrimSpace(string(output)))
+		}
+		return err
+	}
+
+	return nil
+}
+
+func probeTCP(ctx context.Context, address string) error {
+	connection, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+
+	return connection.Close()
+}
+
+func probeHTTP(ctx context.Context, url string) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := healthHTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	io.Copy(ioutil.Discard, io.LimitReader(response.Body, 64*1024))
+
+	if response.StatusCode < 200 || response.StatusCode >= 400 {
+		return fmt.Errorf("HTTP status %d", response.StatusCode)
+	}
+
+	return nil
+}
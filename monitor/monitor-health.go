@@ -0,0 +1,355 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+const (
+	waitReadyPollInterval = 50 * time.Millisecond // how often `WaitReady` looks at the health state
+	defaultStartTimeout   = time.Minute           // how long dependents wait for readiness
+)
+
+// healthChecker - the readiness then the liveness check of one run of a tag
+type healthChecker struct {
+	tag        string
+	generation int64
+	readiness  *healthProbe // `nil` if there is no readiness check
+	liveness   *healthProbe // `nil` if there is no liveness check
+	state      contracts.HealthState
+	cancel     context.CancelFunc
+}
+
+func hasHealthChecks(template contracts.ProcessTemplate) bool {
+	return template.Readiness.Type != contracts.HealthCheckNone || template.Liveness.Type != contracts.HealthCheckNone
+}
+
+// validateHealthChecks - the checks of `template` can be run, the pattern of an output check compiles
+func validateHealthChecks(tag string, template contracts.ProcessTemplate) error {
+	if template.Readiness.Type != contracts.HealthCheckNone {
+		if _, err := newHealthProbe(template.Readiness); err != nil {
+			return fmt.Errorf("%w: %s readiness, %s", contracts.ErrInvalidHealthCheck, tag, err.Error())
+		}
+	}
+
+	if template.Liveness.Type != contracts.HealthCheckNone {
+		if _, err := newHealthProbe(template.Liveness); err != nil {
+			return fmt.Errorf("%w: %s liveness, %s", contracts.ErrInvalidHealthCheck, tag, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// validateAllHealthChecks - `validateHealthChecks` for `tags` of `templates`
+func validateAllHealthChecks(templates map[string]contracts.ProcessTemplate, tags []string) error {
+	for _, tag := range tags {
+		if err := validateHealthChecks(tag, templates[tag]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// startHealthChecks - called after every successful start, the checks end with the run
+func (thisRef *processMonitor) startHealthChecks(tag string, rp contracts.RuningProcess, generation int64) {
+	thisRef.procsSync.Lock()
+	template := thisRef.templates[tag]
+	thisRef.procsSync.Unlock()
+
+	if !hasHealthChecks(template) {
+		return
+	}
+
+	checker := &healthChecker{
+		tag:        tag,
+		generation: generation,
+		state: contracts.HealthState{
+			Status: contracts.HealthStarting,
+		},
+	}
+
+	var err error
+	if template.Readiness.Type != contracts.HealthCheckNone {
+		checker.readiness, err = newHealthProbe(template.Readiness)
+	} else {
+		checker.state.Ready = true
+		checker.state.Status = contracts.HealthHealthy
+	}
+
+	if err == nil && template.Liveness.Type != contracts.HealthCheckNone {
+		checker.liveness, err = newHealthProbe(template.Liveness)
+	}
+
+	// checked by `validateHealthChecks` before the template is monitored, reported and never checked if it fails anyway
+	if err != nil {
+		logging.Errorf("%s: health-FAIL %s, %s", logID, tag, err.Error())
+		checker.readiness, checker.liveness = nil, nil
+		checker.state = contracts.HealthState{
+			Status:    contracts.HealthUnhealthy,
+			LastError: err.Error(),
+		}
+	}
+
+	for _, probe := range []*healthProbe{checker.readiness, checker.liveness} {
+		if probe != nil && probe.pattern != nil {
+			rp.OnStdOut(probe.onOutput, nil)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	checker.cancel = cancel
+
+	thisRef.procsSync.Lock()
+	if state, ok := thisRef.restartStates[tag]; !ok || state.generation != generation {
+		// restarted or removed meanwhile
+		thisRef.procsSync.Unlock()
+		cancel()
+		return
+	}
+	if oldChecker, ok := thisRef.health[tag]; ok {
+		oldChecker.cancel()
+	}
+	thisRef.health[tag] = checker
+	state := checker.state
+	thisRef.procsSync.Unlock()
+
+	thisRef.publishHealth(tag, rp, state)
+
+	go func() {
+		rp.Wait(ctx)
+		cancel()
+	}()
+
+	go thisRef.runHealthChecks(ctx, checker, rp)
+}
+
+// stopHealthChecks - call with `procsSync` held
+func (thisRef *processMonitor) stopHealthChecks(tag string) {
+	if checker, ok := thisRef.health[tag]; ok {
+		checker.cancel()
+		delete(thisRef.health, tag)
+	}
+}
+
+func (thisRef *processMonitor) runHealthChecks(ctx context.Context, checker *healthChecker, rp contracts.RuningProcess) {
+	defer func() {
+		thisRef.procsSync.Lock()
+		current := thisRef.health[checker.tag] == checker
+		if current {
+			delete(thisRef.health, checker.tag)
+		}
+		thisRef.procsSync.Unlock()
+
+		if current {
+			thisRef.publishHealth(checker.tag, rp, contracts.HealthState{Status: contracts.HealthUnknown})
+		}
+	}()
+
+	processID := rp.Details().ProcessID
+
+	if probe := checker.readiness; probe != nil {
+		if !sleepContext(ctx, probe.check.InitialDelay) {
+			return
+		}
+
+		for {
+			err := probe.run(ctx, processID)
+			if ctx.Err() != nil {
+				return
+			}
+
+			if thisRef.recordHealth(checker, rp, probe, err, true).Ready {
+				logging.Debugf("%s: health-READY %s", logID, checker.tag)
+				break
+			}
+
+			if !sleepContext(ctx, probe.check.Interval) {
+				return
+			}
+		}
+	}
+
+	if probe := checker.liveness; probe != nil {
+		if !sleepContext(ctx, probe.check.InitialDelay) {
+			return
+		}
+
+		previousStatus := contracts.HealthHealthy
+		for {
+			err := probe.run(ctx, processID)
+			if ctx.Err() != nil {
+				return
+			}
+
+			state := thisRef.recordHealth(checker, rp, probe, err, false)
+
+			if state.Status == contracts.HealthUnhealthy && previousStatus != contracts.HealthUnhealthy {
+				logging.Warningf("%s: health-UNHEALTHY %s, %d failures, %s", logID, checker.tag, state.ConsecutiveFailures, state.LastError)
+
+				if probe.check.RestartOnFailure {
+					thisRef.restartUnhealthy(checker)
+					return
+				}
+			}
+			previousStatus = state.Status
+
+			if !sleepContext(ctx, probe.check.Interval) {
+				return
+			}
+		}
+	}
+
+	<-ctx.Done()
+}
+
+// recordHealth - updates the state with a check result, publishes status changes
+func (thisRef *processMonitor) recordHealth(checker *healthChecker, rp contracts.RuningProcess, probe *healthProbe, err error, readiness bool) contracts.HealthState {
+	thisRef.procsSync.Lock()
+
+	state := &checker.state
+	previousStatus := state.Status
+
+	state.LastCheckAt = time.Now()
+	if err != nil {
+		state.ConsecutiveFailures++
+		state.ConsecutiveSuccesses = 0
+		state.LastError = err.Error()
+	} else {
+		state.ConsecutiveSuccesses++
+		state.ConsecutiveFailures = 0
+		state.LastError = ""
+	}
+
+	if readiness {
+		if state.ConsecutiveSuccesses >= probe.check.SuccessThreshold {
+			state.Ready = true
+			state.Status = contracts.HealthHealthy
+
+			// liveness counts from its own first check
+			state.ConsecutiveSuccesses = 0
+		}
+	} else {
+		if state.ConsecutiveFailures >= probe.check.FailureThreshold {
+			state.Status = contracts.HealthUnhealthy
+		} else if state.ConsecutiveSuccesses >= probe.check.SuccessThreshold {
+			state.Status = contracts.HealthHealthy
+		}
+	}
+
+	current := *state
+	thisRef.procsSync.Unlock()
+
+	if current.Status != previousStatus {
+		thisRef.publishHealth(checker.tag, rp, current)
+	}
+
+	return current
+}
+
+// restartUnhealthy - unless the run was already stopped or replaced, the restart goes through the restart policy
+// like an exit does, a process without restarts left stays stopped
+func (thisRef *processMonitor) restartUnhealthy(checker *healthChecker) {
+	thisRef.procsSync.Lock()
+	state, ok := thisRef.restartStates[checker.tag]
+	stale := !ok || state.generation != checker.generation || state.stopRequested
+	thisRef.procsSync.Unlock()
+
+	if stale {
+		return
+	}
+
+	logging.Infof("%s: stop %s, unhealthy", logID, checker.tag)
+
+	if err := thisRef.stop(checker.tag, 3, 0*time.Millisecond, false); err != nil {
+		logging.Errorf("%s: restart-FAIL %s, %s", logID, checker.tag, err.Error())
+		return
+	}
+
+	thisRef.procsSync.Lock()
+
+	// stopped or started by the user meanwhile
+	state, ok = thisRef.restartStates[checker.tag]
+	rp, procExists := thisRef.procs[checker.tag]
+	if !ok || !procExists || state.generation != checker.generation || state.stoppedByUser || state.pendingRestart != nil {
+		thisRef.procsSync.Unlock()
+		return
+	}
+
+	thisRef.restartWithBackoff(checker.tag, state, rp, "unhealthy")
+}
+
+func (thisRef *processMonitor) publishHealth(tag string, rp contracts.RuningProcess, state contracts.HealthState) {
+	event := newMonitorEvent(contracts.MonitorEventHealthChanged, tag, rp)
+	event.Health = state
+	thisRef.events.publish(event)
+}
+
+// GetHealth - readiness and liveness of the tag, a running process without checks is healthy
+func (thisRef *processMonitor) GetHealth(tag string) contracts.HealthState {
+	running := thisRef.GetProcess(tag).IsRunning()
+
+	thisRef.procsSync.Lock()
+	defer thisRef.procsSync.Unlock()
+
+	// CHECK-IF-EXISTS
+	state, ok := thisRef.restartStates[tag]
+	if !ok || !running {
+		return contracts.HealthState{}
+	}
+
+	if checker, ok := thisRef.health[tag]; ok && checker.generation == state.generation {
+		return checker.state
+	}
+
+	// checks not started yet
+	if hasHealthChecks(thisRef.templates[tag]) {
+		return contracts.HealthState{Status: contracts.HealthStarting}
+	}
+
+	return contracts.HealthState{
+		Status: contracts.HealthHealthy,
+		Ready:  true,
+	}
+}
+
+// WaitReady - blocks until the readiness check of the tag passed, fails if it stops running or `ctx` is done
+func (thisRef *processMonitor) WaitReady(ctx context.Context, tag string) error {
+	for {
+		if thisRef.GetHealth(tag).Ready {
+			return nil
+		}
+
+		if !thisRef.GetProcess(tag).IsRunning() {
+			return fmt.Errorf("%w: %s is not running", contracts.ErrNotReady, tag)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s, %s", contracts.ErrNotReady, tag, ctx.Err().Error())
+		case <-time.After(waitReadyPollInterval):
+		}
+	}
+}
+
+// sleepContext - `false` if `ctx` is done first
+func sleepContext(ctx context.Context, duration time.Duration) bool {
+	if duration <= 0 {
+		return ctx.Err() == nil
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/internal"
+)
+
+// forwardedSignals - what `EnableReaper` forwards, the termination ones also stop the restarts
+var forwardedSignals = []struct {
+	signal      os.Signal
+	name        string
+	termination bool
+}{
+	{syscall.SIGTERM, "SIGTERM", true},
+	{syscall.SIGINT, "SIGINT", true},
+	{syscall.SIGQUIT, "SIGQUIT", true},
+	{syscall.SIGHUP, "SIGHUP", false},
+}
+
+// EnableSubreaper - Linux only, orphans of the monitored processes are reparented to this process and reaped,
+// for the whole process and not only this monitor, children started elsewhere in this process are left alone,
+// orphans are reliably told apart from them with `NewProcessGroup` or `NewSession`
+func (thisRef *processMonitor) EnableSubreaper() error {
+	if err := internal.EnableSubreaper(); err != nil {
+		logging.Errorf("%s: subreaper-FAIL, %s", logID, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// EnableReaper - Linux only, for a monitor that runs as PID 1 in a container, reaps every child that exits and
+// forwards SIGTERM, SIGINT, SIGQUIT and SIGHUP to the monitored processes, the application still decides when to exit
+func (thisRef *processMonitor) EnableReaper() error {
+	if err := internal.EnableReaper(); err != nil {
+		logging.Errorf("%s: reaper-FAIL, %s", logID, err.Error())
+		return err
+	}
+
+	thisRef.procsSync.Lock()
+	defer thisRef.procsSync.Unlock()
+
+	if thisRef.forwarding {
+		return nil
+	}
+	thisRef.forwarding = true
+
+	signals := make(chan os.Signal, 1)
+	for _, forwarded := range forwardedSignals {
+		signal.Notify(signals, forwarded.signal)
+	}
+
+	go func() {
+		for received := range signals {
+			thisRef.forwardSignal(received)
+		}
+	}()
+
+	return nil
+}
+
+// forwardSignal - to every running process, after a termination signal their exits are not restarted
+func (thisRef *processMonitor) forwardSignal(received os.Signal) {
+	name, termination := "", false
+	for _, forwarded := range forwardedSignals {
+		if forwarded.signal == received {
+			name, termination = forwarded.name, forwarded.termination
+		}
+	}
+
+	logging.Debugf("%s: forward-SIGNAL %s", logID, name)
+
+	for _, tag := range thisRef.GetAllTags() {
+		thisRef.procsSync.Lock()
+		rp, ok := thisRef.procs[tag]
+		if ok && termination {
+			state := thisRef.restartStates[tag]
+			state.stopRequested = true
+			state.stoppedByUser = true
+			state.cancelPendingRestart()
+		}
+		thisRef.procsSync.Unlock()
+
+		if !ok || !rp.IsRunning() {
+			continue
+		}
+
+		if err := rp.Signal(name); err != nil {
+			logging.Warningf("%s: forward-SIGNAL-FAIL %s to %s, %s", logID, name, tag, err.Error())
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package monitor
+
+import (
+	"fmt"
+	"sort"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+	"github.com/codemodify/systemkit-processes/helpers"
+)
+
+// PlanReload - what `Reload` would do, nothing is applied
+func (thisRef *processMonitor) PlanReload(desired map[string]contracts.ProcessTemplate) contracts.ReloadPlan {
+	thisRef.procsSync.Lock()
+	defer thisRef.procsSync.Unlock()
+
+	steps := []contracts.ReloadStep{}
+
+	for tag, current := range thisRef.templates {
+		desiredTemplate, ok := desired[tag]
+		switch {
+		case !ok:
+			steps = append(steps, contracts.ReloadStep{Tag: tag, Action: contracts.ReloadRemove})
+		case !sameTemplate(current, desiredTemplate):
+			steps = append(steps, contracts.ReloadStep{Tag: tag, Action: contracts.ReloadRestart})
+
+		default:
+			steps = append(steps, contracts.ReloadStep{Tag: tag, Action: contracts.ReloadKeep})
+		}
+	}
+
+	for tag := range desired {
+		if _, ok := thisRef.templates[tag]; !ok {
+			steps = append(steps, contracts.ReloadStep{Tag: tag, Action: contracts.ReloadAdd})
+		}
+	}
+
+	sort.Slice(steps, func(i, j int) bool {
+		if steps[i].Action != steps[j].Action {
+			return steps[i].Action < steps[j].Action
+		}
+		return steps[i].Tag < steps[j].Tag
+	})
+
+	return contracts.ReloadPlan{
+		Steps: steps,
+	}
+}
+
+// Reload - applies `PlanReload`, unchanged tags are not touched, keeps going on errors
+//
+// Removed and changed tags are stopped in reverse dependency order, changed and added ones are started in
+// dependency order, nothing is applied if the dependencies of the desired set are broken or a health check is invalid
+func (thisRef *processMonitor) Reload(desired map[string]contracts.ProcessTemplate) (contracts.ReloadPlan, error) {
+	plan := thisRef.PlanReload(desired)
+
+	logging.Debugf("%s: reload %s", logID, helpers.AsJSONString(plan))
+
+	toStop := []string{}
+	toStart := []string{}
+	for _, step := range plan.Steps {
+		switch step.Action {
+		case contracts.ReloadRemove:
+			toStop = append(toStop, step.Tag)
+		case contracts.ReloadRestart:
+			toStop = append(toStop, step.Tag)
+			toStart = append(toStart, step.Tag)
+		case contracts.ReloadAdd:
+			toStart = append(toStart, step.Tag)
+		}
+	}
+	sort.Strings(toStop)
+	sort.Strings(toStart)
+
+	startLevelsOfDesired, err := startLevels(desired, toStart)
+	if err == nil {
+		err = validateAllHealthChecks(desired, toStart)
+	}
+	if err != nil {
+		logging.Errorf("%s: reload-FAIL, %s", logID, err.Error())
+		for i, step := range plan.Steps {
+			if step.Action != contracts.ReloadKeep {
+				plan.Steps[i].Error = err.Error()
+			}
+		}
+
+		return plan, fmt.Errorf("%w: %s", contracts.ErrReloadFailed, err.Error())
+	}
+
+	thisRef.procsSync.Lock()
+	current := map[string]contracts.ProcessTemplate{}
+	for tag, template := range thisRef.templates {
+		current[tag] = template
+	}
+	thisRef.procsSync.Unlock()
+
+	stopLevels, err := startLevels(current, toStop)
+	if err != nil {
+		// no order to follow, stop everything at once
+		logging.Warningf("%s: reload-stop-UNORDERED, %s", logID, err.Error())
+		stopLevels = [][]string{toStop}
+	}
+
+	errs := thisRef.stopInReverseOrder(stopLevels)
+
+	for _, step := range plan.Steps {
+		switch step.Action {
+		case contracts.ReloadRemove:
+			// a tag that did not stop stays monitored, it can be stopped or reloaded again
+			if errs[step.Tag] == nil {
+				thisRef.RemoveFromMonitor(step.Tag)
+			}
+		case contracts.ReloadRestart, contracts.ReloadAdd:
+			if errs[step.Tag] == nil {
+				thisRef.add(desired[step.Tag], step.Tag)
+			}
+		}
+	}
+
+	// a tag that did not stop keeps running its old template, it is not started again
+	startLevelsOfDesired = withoutTags(startLevelsOfDesired, errs)
+
+	startErrs, _ := thisRef.startInOrder(startLevelsOfDesired)
+	for tag, err := range startErrs {
+		errs[tag] = err
+	}
+
+	var reloadErr error
+	for i, step := range plan.Steps {
+		if err := errs[step.Tag]; err != nil {
+			logging.Errorf("%s: reload-FAIL %s %s, %s", logID, step.Action, step.Tag, err.Error())
+			plan.Steps[i].Error = err.Error()
+			reloadErr = contracts.ErrReloadFailed
+		}
+	}
+
+	return plan, reloadErr
+}
+
+// withoutTags - `levels` without the tags that have an error
+func withoutTags(levels [][]string, errs map[string]error) [][]string {
+	result := [][]string{}
+	for _, level := range levels {
+		kept := []string{}
+		for _, tag := range level {
+			if errs[tag] == nil {
+				kept = append(kept, tag)
+			}
+		}
+		result = append(result, kept)
+	}
+
+	return result
+}
+
+// sameTemplate - compares what can be serialized, readers and delegates are not compared
+func sameTemplate(a contracts.ProcessTemplate, b contracts.ProcessTemplate) bool {
+	return helpers.AsJSONString(a) == helpers.AsJSONString(b)
+}
@@ -0,0 +1,178 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// restartState - restart bookkeeping for a tag
+type restartState struct {
+	policy        contracts.RestartPolicy
+	generation    int64       // bumped on every start, used to ignore exit notifications from previous runs
+	stopRequested bool        // set by `Monitor.Stop`, cleared by `Monitor.Start`
+	stoppedByUser bool        // set by `Monitor.Stop`, kept across re-spawns, cleared by `Monitor.Start`, used by `RestartUnlessStopped`
+	restarts      []time.Time // when the restarts inside the sliding window happened
+	gaveUp        bool        // `MaxRestarts` was reached, cleared by `Monitor.Start`
+
+	backoffAttempt int
+	currentDelay   time.Duration
+	nextRestartAt  time.Time
+	pendingRestart *time.Timer
+}
+
+// exitNotice - passed as params to `OnStop` to identify the run that exited
+type exitNotice struct {
+	tag        string
+	generation int64
+}
+
+func newRestartState(policy contracts.RestartPolicy) *restartState {
+	return &restartState{
+		policy:   policy,
+		restarts: []time.Time{},
+	}
+}
+
+// shouldRestart - consults the policy and the exit code of the run that ended
+func (thisRef *restartState) shouldRestart(exitCode int) bool {
+	if thisRef.stopRequested {
+		return false
+	}
+
+	switch thisRef.policy.Mode {
+	case contracts.RestartAlways, contracts.RestartUnlessStopped:
+		return true
+	case contracts.RestartOnFailure:
+		return exitCode != 0
+
+	default:
+		return false
+	}
+}
+
+// keptStopped - `RestartUnlessStopped` after `Monitor.Stop`, only an explicit `Monitor.Start` starts it again
+func (thisRef *restartState) keptStopped() bool {
+	return thisRef.policy.Mode == contracts.RestartUnlessStopped && thisRef.stoppedByUser
+}
+
+// takeRestart - records a restart if the sliding window still allows one
+func (thisRef *restartState) takeRestart(now time.Time) bool {
+	if thisRef.policy.Window > 0 {
+		windowStart := now.Add(-thisRef.policy.Window)
+
+		kept := []time.Time{}
+		for _, restartedAt := range thisRef.restarts {
+			if restartedAt.After(windowStart) {
+				kept = append(kept, restartedAt)
+			}
+		}
+		thisRef.restarts = kept
+	}
+
+	if thisRef.policy.MaxRestarts > 0 && len(thisRef.restarts) >= thisRef.policy.MaxRestarts {
+		return false
+	}
+
+	thisRef.restarts = append(thisRef.restarts, now)
+
+	return true
+}
+
+// onProcessExited - `OnStop` delegate registered for every run started by the monitor
+func (thisRef *processMonitor) onProcessExited(params interface{}, exitStatus contracts.ExitStatus) {
+	notice := params.(exitNotice)
+
+	thisRef.procsSync.Lock()
+
+	state, stateExists := thisRef.restartStates[notice.tag]
+	rp, procExists := thisRef.procs[notice.tag]
+	if !stateExists || !procExists || state.generation != notice.generation {
+		thisRef.procsSync.Unlock()
+		return
+	}
+
+	event := newMonitorEvent(contracts.MonitorEventExited, notice.tag, rp)
+	event.ExitStatus = exitStatus
+	thisRef.events.publish(event)
+
+	if exitStatus.OOMKilled {
+		logging.Warningf("%s: oom-killed %s", logID, notice.tag)
+
+		event = newMonitorEvent(contracts.MonitorEventOOMKilled, notice.tag, rp)
+		event.ExitStatus = exitStatus
+		thisRef.events.publish(event)
+	}
+
+	exitCode := exitStatus.Code
+	if !state.shouldRestart(exitCode) {
+		thisRef.procsSync.Unlock()
+		logging.Debugf("%s: exited %s, exit code %d, no restart", logID, notice.tag, exitCode)
+		return
+	}
+
+	thisRef.restartWithBackoff(notice.tag, state, rp, fmt.Sprintf("exit code %d", exitCode))
+}
+
+// restartWithBackoff - counts the restart against `MaxRestarts` and waits the backoff delay,
+// call with `procsSync` held, it is released
+func (thisRef *processMonitor) restartWithBackoff(tag string, state *restartState, rp contracts.RuningProcess, reason string) {
+	now := time.Now()
+	if !state.takeRestart(now) {
+		state.gaveUp = true
+		thisRef.procsSync.Unlock()
+		logging.Warningf("%s: restart-GIVE-UP %s, %s, %d restarts within %v", logID, tag, reason, state.policy.MaxRestarts, state.policy.Window)
+		return
+	}
+
+	notice := exitNotice{
+		tag:        tag,
+		generation: state.generation,
+	}
+
+	delay := state.scheduleRestart(now.Sub(rp.StartedAt()), now)
+	if delay > 0 {
+		state.pendingRestart = time.AfterFunc(delay, func() {
+			thisRef.restartAfterBackoff(notice)
+		})
+		thisRef.procsSync.Unlock()
+
+		logging.Infof("%s: restart %s in %v, %s", logID, tag, delay, reason)
+		return
+	}
+
+	thisRef.procsSync.Unlock()
+
+	logging.Infof("%s: restart %s, %s", logID, tag, reason)
+
+	thisRef.restart(tag)
+}
+
+// restartAfterBackoff - fires when the backoff delay for `notice` elapsed
+func (thisRef *processMonitor) restartAfterBackoff(notice exitNotice) {
+	thisRef.procsSync.Lock()
+
+	state, stateExists := thisRef.restartStates[notice.tag]
+	// every stop and start cancels the pending restart, a restart after an unhealthy stop is pending while stopped
+	if !stateExists || state.generation != notice.generation || state.pendingRestart == nil {
+		thisRef.procsSync.Unlock()
+		return
+	}
+	state.pendingRestart = nil
+
+	thisRef.procsSync.Unlock()
+
+	thisRef.restart(notice.tag)
+}
+
+func (thisRef *processMonitor) restart(tag string) {
+	err := thisRef.Start(tag)
+	if err != nil {
+		logging.Errorf("%s: restart-FAIL %s, %s", logID, tag, err.Error())
+		return
+	}
+
+	thisRef.publishEvent(contracts.MonitorEventRestarted, tag, thisRef.GetProcess(tag))
+}
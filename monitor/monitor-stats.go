@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"sort"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+	"github.com/codemodify/systemkit-processes/contracts"
+)
+
+// defaultStatsHistory - samples kept per tag when `SampleStats` is given 0
+const defaultStatsHistory = 60
+
+// statsSampler - samples every monitored process on a ticker
+type statsSampler struct {
+	interval    time.Duration
+	historySize int
+	done        chan struct{}
+}
+
+// SampleStats - samples every running process each `interval` and keeps the last `historySize`, an `interval` of 0 stops sampling
+//
+// The CPU percent of a sample is measured from the previous `Stats()` of the process, calling it elsewhere shortens that
+func (thisRef *processMonitor) SampleStats(interval time.Duration, historySize int) {
+	if historySize <= 0 {
+		historySize = defaultStatsHistory
+	}
+
+	thisRef.procsSync.Lock()
+	defer thisRef.procsSync.Unlock()
+
+	if thisRef.sampler != nil {
+		close(thisRef.sampler.done)
+		thisRef.sampler = nil
+	}
+
+	if interval <= 0 {
+		logging.Debugf("%s: stats-sampling-STOP", logID)
+		return
+	}
+
+	logging.Debugf("%s: stats-sampling every %v, %d samples", logID, interval, historySize)
+
+	thisRef.sampler = &statsSampler{
+		interval:    interval,
+		historySize: historySize,
+		done:        make(chan struct{}),
+	}
+
+	go thisRef.runSampler(thisRef.sampler)
+}
+
+func (thisRef *processMonitor) runSampler(sampler *statsSampler) {
+	ticker := time.NewTicker(sampler.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			thisRef.sampleAll(sampler)
+		case <-sampler.done:
+			return
+		}
+	}
+}
+
+// sampleAll - one sample per running process, processes that are not running are skipped
+func (thisRef *processMonitor) sampleAll(sampler *statsSampler) {
+	thisRef.procsSync.Lock()
+	procs := map[string]contracts.RuningProcess{}
+	for tag, rp := range thisRef.procs {
+		procs[tag] = rp
+	}
+	thisRef.procsSync.Unlock()
+
+	tags := []string{}
+	for tag := range procs {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	for _, tag := range tags {
+		stats, err := procs[tag].Stats()
+		if err != nil {
+			continue
+		}
+
+		thisRef.procsSync.Lock()
+		// removed meanwhile
+		if rp, ok := thisRef.procs[tag]; ok && rp == procs[tag] {
+			history := append(thisRef.statsHistory[tag], stats)
+			if len(history) > sampler.historySize {
+				history = history[len(history)-sampler.historySize:]
+			}
+			thisRef.statsHistory[tag] = history
+		}
+		thisRef.procsSync.Unlock()
+	}
+}
+
+// GetStats - the sampled history of the tag, oldest first, across restarts
+func (thisRef *processMonitor) GetStats(tag string) []contracts.ProcessStats {
+	thisRef.procsSync.Lock()
+	defer thisRef.procsSync.Unlock()
+
+	return append([]contracts.ProcessStats{}, thisRef.statsHistory[tag]...)
+}
@@ -15,39 +15,104 @@ const logID = "PROCESS-MONITOR"
 
 // processMonitor - Represents Windows service
 type processMonitor struct {
-	procs        map[string]contracts.RuningProcess
-	procsSync    *sync.Mutex
-	procTagIndex int64
+	procs         map[string]contracts.RuningProcess
+	procsSync     *sync.Mutex
+	procTagIndex  int64
+	restartStates map[string]*restartState
+	templates     map[string]contracts.ProcessTemplate // as spawned, for `Reload`
+	health        map[string]*healthChecker
+	statsHistory  map[string][]contracts.ProcessStats
+	sampler       *statsSampler // `nil` unless `SampleStats` was called
+	events        *eventHub
+	forwarding    bool // signals are forwarded, set by `EnableReaper`
 }
 
 // New -
 func New() contracts.Monitor {
 	return &processMonitor{
-		procs:        map[string]contracts.RuningProcess{},
-		procsSync:    &sync.Mutex{},
-		procTagIndex: 0,
+		procs:         map[string]contracts.RuningProcess{},
+		procsSync:     &sync.Mutex{},
+		procTagIndex:  0,
+		restartStates: map[string]*restartState{},
+		templates:     map[string]contracts.ProcessTemplate{},
+		health:        map[string]*healthChecker{},
+		statsHistory:  map[string][]contracts.ProcessStats{},
+		sampler:       nil,
+		events:        newEventHub(),
+		forwarding:    false,
 	}
 }
 
 // Spawn -
 func (thisRef *processMonitor) Spawn(processTemplate contracts.ProcessTemplate) (string, error) {
 	thisRef.procsSync.Lock()
-	defer thisRef.procsSync.Unlock()
-
 	tag := fmt.Sprintf("gen-tag-%d", thisRef.procTagIndex)
 	thisRef.procTagIndex++
+	thisRef.procsSync.Unlock()
 
 	return tag, thisRef.SpawnWithTag(processTemplate, tag)
 }
 
 // SpawnWithID -
 func (thisRef *processMonitor) SpawnWithTag(processTemplate contracts.ProcessTemplate, tag string) error {
+	if err := validateHealthChecks(tag, processTemplate); err != nil {
+		logging.Errorf("%s: spawn-FAIL, %s", logID, err.Error())
+		return err
+	}
+
+	thisRef.add(processTemplate, tag)
+
+	return thisRef.startUnlessStopped(tag)
+}
+
+// add - monitors the process without starting it
+func (thisRef *processMonitor) add(processTemplate contracts.ProcessTemplate, tag string) {
 	logging.Debugf("%s: spawn %s, %s", logID, tag, helpers.AsJSONString(processTemplate))
 
+	spawnedTemplate := processTemplate
+
+	// parsed log records tell which process they came from
+	if len(processTemplate.LogParsing.Tag) == 0 {
+		processTemplate.LogParsing.Tag = tag
+	}
+
+	// one cgroup per tag
+	if len(processTemplate.Cgroup.Name) == 0 {
+		processTemplate.Cgroup.Name = tag
+	}
+
+	thisRef.procsSync.Lock()
+	state := newRestartState(processTemplate.RestartPolicy)
+	if oldState, ok := thisRef.restartStates[tag]; ok {
+		oldState.cancelPendingRestart()
+		state.generation = oldState.generation // exits of the replaced process stay ignored
+		state.stoppedByUser = oldState.stoppedByUser
+	}
+	thisRef.stopHealthChecks(tag)
+	if oldRp, ok := thisRef.procs[tag]; ok {
+		oldRp.Close()
+	}
+	rp := internal.NewRuningProcess(processTemplate)
+	thisRef.procs[tag] = rp
+	thisRef.restartStates[tag] = state
+	thisRef.templates[tag] = spawnedTemplate
+	thisRef.procsSync.Unlock()
+
+	thisRef.publishEvent(contracts.MonitorEventSpawned, tag, rp)
+}
+
+// startUnlessStopped - `Start`, unless the user stopped a `RestartUnlessStopped` tag
+func (thisRef *processMonitor) startUnlessStopped(tag string) error {
 	thisRef.procsSync.Lock()
-	thisRef.procs[tag] = internal.NewRuningProcess(processTemplate)
+	state, ok := thisRef.restartStates[tag]
+	keptStopped := ok && state.keptStopped()
 	thisRef.procsSync.Unlock()
 
+	if keptStopped {
+		logging.Debugf("%s: start-SKIP %s, stopped by the user", logID, tag)
+		return nil
+	}
+
 	return thisRef.Start(tag)
 }
 
@@ -58,21 +123,48 @@ func (thisRef *processMonitor) Start(tag string) error {
 	}
 
 	thisRef.procsSync.Lock()
-	defer thisRef.procsSync.Unlock()
 
 	// CHECK-IF-EXISTS
-	if _, ok := thisRef.procs[tag]; !ok {
+	rp, ok := thisRef.procs[tag]
+	if !ok {
+		thisRef.procsSync.Unlock()
 		return fmt.Errorf("ID %s, CHECK-IF-EXISTS failed", tag)
 	}
 
 	logging.Debugf("%s: start %s", logID, tag)
 
-	err := thisRef.procs[tag].Start()
+	state := thisRef.restartStates[tag]
+	state.cancelPendingRestart()
+	state.stopRequested = false
+	state.stoppedByUser = false
+	state.gaveUp = false
+	state.generation++
+	notice := exitNotice{
+		tag:        tag,
+		generation: state.generation,
+	}
+
+	err := rp.Start()
+
+	thisRef.procsSync.Unlock()
+
 	if err != nil {
-		logging.Errorf("%s: start-FAIL %s, %s", logID, thisRef.procs[tag], err.Error())
+		logging.Errorf("%s: start-FAIL %s, %s", logID, tag, err.Error())
+
+		event := newMonitorEvent(contracts.MonitorEventStartFailed, tag, rp)
+		event.Error = err.Error()
+		thisRef.events.publish(event)
+
 		return err
 	}
 
+	thisRef.publishEvent(contracts.MonitorEventStarted, tag, rp)
+
+	// outside the lock, the delegate is called right away if the process already exited
+	rp.OnStop(thisRef.onProcessExited, notice)
+
+	thisRef.startHealthChecks(tag, rp, notice.generation)
+
 	return nil
 }
 
@@ -81,11 +173,13 @@ func (thisRef *processMonitor) Stop(tag string) error {
 	return thisRef.StopWithTimeout(tag, 3, 0*time.Millisecond)
 }
 
+// StopWithTimeout -
 func (thisRef *processMonitor) StopWithTimeout(tag string, attempts int, waitTimeout time.Duration) error {
-	if !thisRef.GetProcess(tag).IsRunning() {
-		return nil
-	}
+	return thisRef.stop(tag, attempts, waitTimeout, true)
+}
 
+// stop - `byUser` marks the tag as stopped for `RestartUnlessStopped`
+func (thisRef *processMonitor) stop(tag string, attempts int, waitTimeout time.Duration, byUser bool) error {
 	thisRef.procsSync.Lock()
 
 	// CHECK-IF-EXISTS
@@ -97,14 +191,32 @@ func (thisRef *processMonitor) StopWithTimeout(tag string, attempts int, waitTim
 		return nil
 	}
 
+	// no restarts for exits caused by this stop
+	state := thisRef.restartStates[tag]
+	state.stopRequested = true
+	state.cancelPendingRestart()
+	if byUser {
+		state.stoppedByUser = true
+	}
+
 	thisRef.procsSync.Unlock()
 
-	return rp.Stop(tag, attempts, waitTimeout)
+	if !rp.IsRunning() {
+		return nil
+	}
+
+	thisRef.publishEvent(contracts.MonitorEventStopping, tag, rp)
+
+	err := rp.Stop(tag, attempts, waitTimeout)
+
+	thisRef.publishEvent(contracts.MonitorEventStopped, tag, rp)
+
+	return err
 }
 
 // Restart -
 func (thisRef *processMonitor) Restart(tag string) error {
-	err := thisRef.Stop(tag)
+	err := thisRef.stop(tag, 3, 0*time.Millisecond, false)
 	if err != nil {
 		return err
 	}
@@ -117,9 +229,15 @@ func (thisRef *processMonitor) StopAllInParallel() {
 	thisRef.procsSync.Lock()
 	defer thisRef.procsSync.Unlock()
 
+	// dependents first, then what they depend on
+	if thisRef.hasDependencies() {
+		go thisRef.StopAll()
+		return
+	}
+
 	for k := range thisRef.procs {
 		go func(tag string) {
-			thisRef.Stop(tag)
+			thisRef.stop(tag, 3, 0*time.Millisecond, false)
 		}(k)
 	}
 }
@@ -140,10 +258,22 @@ func (thisRef *processMonitor) GetProcess(tag string) contracts.RuningProcess {
 // RemoveFromMonitor -
 func (thisRef *processMonitor) RemoveFromMonitor(tag string) {
 	thisRef.procsSync.Lock()
-	defer thisRef.procsSync.Unlock()
 
-	if _, ok := thisRef.procs[tag]; ok {
+	rp, ok := thisRef.procs[tag]
+	if ok {
 		delete(thisRef.procs, tag) // delete
+		rp.Close()
+		thisRef.restartStates[tag].cancelPendingRestart()
+		delete(thisRef.restartStates, tag)
+		delete(thisRef.templates, tag)
+		thisRef.stopHealthChecks(tag)
+		delete(thisRef.statsHistory, tag)
+	}
+
+	thisRef.procsSync.Unlock()
+
+	if ok {
+		thisRef.publishEvent(contracts.MonitorEventRemoved, tag, rp)
 	}
 }
 
@@ -159,3 +289,16 @@ func (thisRef *processMonitor) GetAllTags() []string {
 
 	return allTags
 }
+
+// GetRestartState - restart and backoff bookkeeping for the tag
+func (thisRef *processMonitor) GetRestartState(tag string) contracts.RestartState {
+	thisRef.procsSync.Lock()
+	defer thisRef.procsSync.Unlock()
+
+	// CHECK-IF-EXISTS
+	if _, ok := thisRef.restartStates[tag]; !ok {
+		return contracts.RestartState{}
+	}
+
+	return thisRef.restartStates[tag].asContract()
+}
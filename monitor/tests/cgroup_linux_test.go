@@ -0,0 +1,209 @@
+// +build linux
+
+package tests
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"github.com/codemodify/systemkit-processes/internal"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+// TestCgroupFakeFSLinux - a plain folder stands in for cgroupfs, the files are checked as the kernel would read them
+func TestCgroupFakeFSLinux(t *testing.T) {
+	const logID = "TestCgroupFakeFSLinux"
+
+	logging.Debugf("%s: START", logID)
+
+	internal.CgroupFakeFS = true
+	defer func() { internal.CgroupFakeFS = false }()
+
+	parent, err := ioutil.TempDir("", "cgroup")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(parent)
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	events, unsubscribe := monitor.Events()
+	defer unsubscribe()
+
+	err = monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		Cgroup: contracts.Cgroup{
+			Parent:    parent,
+			MemoryMax: 64 * 1024 * 1024,
+			CPUMax:    0.5,
+			PidsMax:   32,
+			IOWeight:  200,
+		},
+	}, "limited")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("limited")
+	group := filepath.Join(parent, "limited")
+
+	expected := map[string]string{
+		filepath.Join(parent, "cgroup.subtree_control"): "+memory +cpu +pids +io",
+		filepath.Join(group, "memory.max"):              "67108864",
+		filepath.Join(group, "cpu.max"):                 "50000 100000",
+		filepath.Join(group, "pids.max"):                "32",
+		filepath.Join(group, "io.weight"):               "default 200",
+		filepath.Join(group, "cgroup.procs"):            strconv.Itoa(rp.Details().ProcessID),
+	}
+	for file, value := range expected {
+		if data, _ := ioutil.ReadFile(file); string(data) != value {
+			t.Fatalf("expected %q in %s, got %q", value, file, string(data))
+		}
+	}
+
+	// what the kernel would report
+	ioutil.WriteFile(filepath.Join(group, "memory.current"), []byte("1048576\n"), 0644)
+	ioutil.WriteFile(filepath.Join(group, "cpu.stat"), []byte("usage_usec 300\nuser_usec 200\nsystem_usec 100\n"), 0644)
+
+	stats, err := rp.CgroupStats()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if stats.Path != group || stats.MemoryCurrent != 1048576 || stats.CPUUsageUsec != 300 || stats.CPUSystemUsec != 100 {
+		t.Fatalf("bad stats %+v", stats)
+	}
+
+	// the OOM killer strikes
+	ioutil.WriteFile(filepath.Join(group, "memory.events"), []byte("low 0\nhigh 0\nmax 3\noom 1\noom_kill 1\n"), 0644)
+	syscall.Kill(rp.Details().ProcessID, syscall.SIGKILL)
+
+	exited := false
+	timeout := time.After(5 * time.Second)
+	for !exited {
+		select {
+		case event := <-events:
+			if event.Type == contracts.MonitorEventExited {
+				if !event.ExitStatus.OOMKilled {
+					t.Fatalf("expected an OOM kill, got %+v", event.ExitStatus)
+				}
+			}
+			exited = event.Type == contracts.MonitorEventOOMKilled
+		case <-timeout:
+			t.Fatalf("no OOM kill event")
+		}
+	}
+
+	// what was written for the group is removed after the exit, what the kernel wrote is left alone
+	if _, err := os.Stat(filepath.Join(group, "memory.max")); !os.IsNotExist(err) {
+		t.Fatalf("the group should be removed after the exit")
+	}
+	if _, err := os.Stat(filepath.Join(group, "memory.events")); err != nil {
+		t.Fatalf("expected the other files kept, got %v", err)
+	}
+
+	if _, err := rp.CgroupStats(); err != contracts.ErrCgroupNotAvailable {
+		t.Fatalf("expected ErrCgroupNotAvailable, got %v", err)
+	}
+}
+
+func TestCgroupNotAvailableLinux(t *testing.T) {
+	parent, err := ioutil.TempDir("", "cgroup")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(parent)
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	template := contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		Cgroup: contracts.Cgroup{
+			Parent:    parent,
+			MemoryMax: 64 * 1024 * 1024,
+		},
+	}
+
+	// a plain folder would enforce nothing
+	if err := monitor.SpawnWithTag(template, "plain"); !errors.Is(err, contracts.ErrCgroupNotAvailable) {
+		t.Fatalf("expected ErrCgroupNotAvailable, got %v", err)
+	}
+
+	// a folder that is already there is not taken over, nor removed
+	internal.CgroupFakeFS = true
+	defer func() { internal.CgroupFakeFS = false }()
+
+	data := filepath.Join(parent, "data")
+	if err := os.Mkdir(data, 0755); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ioutil.WriteFile(filepath.Join(data, "keep"), []byte("keep"), 0644)
+
+	if err := monitor.SpawnWithTag(template, "data"); !errors.Is(err, contracts.ErrCgroupNotAvailable) {
+		t.Fatalf("expected ErrCgroupNotAvailable, got %v", err)
+	}
+	monitor.Stop("data")
+
+	if content, _ := ioutil.ReadFile(filepath.Join(data, "keep")); string(content) != "keep" {
+		t.Fatalf("the existing folder was changed")
+	}
+}
+
+// TestCgroupDelegatedLinux - needs `CGROUP2_TEST_PARENT`, a cgroup v2 folder this user can create groups in
+func TestCgroupDelegatedLinux(t *testing.T) {
+	parent := os.Getenv("CGROUP2_TEST_PARENT")
+	if len(parent) == 0 {
+		t.Skip("CGROUP2_TEST_PARENT is not set")
+	}
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "sleep 30 & cat /proc/self/cgroup; wait"},
+		Cgroup: contracts.Cgroup{
+			Parent: parent,
+		},
+	}, "delegated")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("delegated")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rp.Output(0)) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// cloned into the group, so are its children
+	if output := linesAsString(rp.Output(0)); !strings.Contains(output, "/delegated") {
+		t.Fatalf("expected the process in its group, got %s", output)
+	}
+
+	group := filepath.Join(parent, "delegated")
+	if data, _ := ioutil.ReadFile(filepath.Join(group, "cgroup.procs")); len(strings.Fields(string(data))) != 2 {
+		t.Fatalf("expected 2 processes in the group, got %q", string(data))
+	}
+
+	monitor.Stop("delegated")
+
+	if _, err := os.Stat(group); !os.IsNotExist(err) {
+		t.Fatalf("the group should be removed after Stop")
+	}
+}
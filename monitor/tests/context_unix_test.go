@@ -0,0 +1,114 @@
+// +build !windows
+
+package tests
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestContextUnix(t *testing.T) {
+	const logID = "TestContextUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+	})
+	rp := monitor.GetProcess(processTag)
+
+	// WAIT times out while running
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer waitCancel()
+
+	if _, err := rp.Wait(waitCtx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	// STOP
+	if err := rp.StopContext(context.Background()); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// START with a context, cancelling it stops the process
+	startCtx, startCancel := context.WithCancel(context.Background())
+	if err := rp.StartContext(startCtx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	startCancel()
+
+	waitCtx, waitCancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer waitCancel()
+
+	exitStatus, err := rp.Wait(waitCtx)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if exitStatus.Signal != int(syscall.SIGINT) {
+		t.Fatalf("expected SIGINT, got %+v", exitStatus)
+	}
+}
+
+func TestStopContextKillsGroupUnix(t *testing.T) {
+	monitor := procMon.New()
+
+	// both ignore the stop signals, only a kill ends them
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable:      "sh",
+		Args:            []string{"-c", "trap '' INT TERM; sleep 30 & echo $!; wait"},
+		NewProcessGroup: true,
+	})
+	rp := monitor.GetProcess(processTag)
+
+	childPID := make(chan int, 1)
+	once := sync.Once{}
+	rp.OnStdOut(func(params interface{}, outputData []byte) {
+		pid, _ := strconv.Atoi(strings.TrimSpace(string(outputData)))
+		once.Do(func() { childPID <- pid })
+	}, nil)
+
+	var pid int
+	select {
+	case pid = <-childPID:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no child PID")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if err := rp.StopContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for isAlive(pid) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if isAlive(pid) {
+		t.Fatalf("expected the child in the group killed")
+	}
+}
+
+// isAlive - exists and is not a zombie
+func isAlive(pid int) bool {
+	output, _ := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	state := strings.TrimSpace(string(output))
+
+	return len(state) > 0 && !strings.HasPrefix(state, "Z")
+}
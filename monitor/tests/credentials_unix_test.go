@@ -0,0 +1,96 @@
+// +build !windows
+
+package tests
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestCredentialsUnix(t *testing.T) {
+	const logID = "TestCredentialsUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	if os.Geteuid() != 0 {
+		t.Skip("needs root")
+	}
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable:          "sh",
+		Args:                []string{"-c", "id -u; id -g; id -G; echo $HOME $USER $LOGNAME $SHELL; sleep 30"},
+		WorkingDirectory:    "/",
+		Environment:         []string{"HOME=/root", "KEEP=me"},
+		User:                "nobody",
+		Group:               "nogroup",
+		SupplementaryGroups: []string{"nogroup", "0"},
+		UserEnvironment:     true,
+	}, "nobody")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("nobody")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rp.Output(0)) < 4 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	expected := "[stdout 65534][stdout 65534][stdout 65534 0][stdout /nonexistent nobody nobody /usr/sbin/nologin]"
+	if output := linesAsString(rp.Output(0)); output != expected {
+		t.Fatalf("expected %s, got %s", expected, output)
+	}
+
+	if details := rp.Details(); details.UserID != 65534 || details.GroupID != 65534 {
+		t.Fatalf("bad user and group %d:%d", details.UserID, details.GroupID)
+	}
+}
+
+func TestCredentialsErrorsUnix(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	sleep := contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+	}
+
+	unknownUser := sleep
+	unknownUser.User = "no-such-user-here"
+	if err := monitor.SpawnWithTag(unknownUser, "unknown-user"); !errors.Is(err, contracts.ErrUnknownUser) {
+		t.Fatalf("expected ErrUnknownUser, got %v", err)
+	}
+
+	unknownGroup := sleep
+	unknownGroup.Group = "no-such-group-here"
+	if err := monitor.SpawnWithTag(unknownGroup, "unknown-group"); !errors.Is(err, contracts.ErrUnknownGroup) {
+		t.Fatalf("expected ErrUnknownGroup, got %v", err)
+	}
+
+	// only root can become someone else
+	if os.Geteuid() != 0 {
+		notPermitted := sleep
+		notPermitted.User = "0"
+		if err := monitor.SpawnWithTag(notPermitted, "not-permitted"); !errors.Is(err, contracts.ErrCredentialsNotPermitted) {
+			t.Fatalf("expected ErrCredentialsNotPermitted, got %v", err)
+		}
+
+		self := sleep
+		self.User = strconv.Itoa(os.Geteuid())
+		if err := monitor.SpawnWithTag(self, "self"); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// +build !windows
+
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestDependenciesUnix(t *testing.T) {
+	const logID = "TestDependenciesUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	sleep := contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+	}
+	with := func(template contracts.ProcessTemplate, requires []string, after []string) contracts.ProcessTemplate {
+		template.Requires = requires
+		template.After = after
+		return template
+	}
+
+	monitor := procMon.New()
+
+	events, unsubscribe := monitor.Events()
+	defer unsubscribe()
+
+	err := monitor.SpawnAll(map[string]contracts.ProcessTemplate{
+		"worker-1": with(sleep, []string{"queue"}, nil),
+		"worker-2": with(sleep, []string{"queue"}, []string{"worker-1"}),
+		"queue":    with(sleep, []string{"db"}, nil),
+		"db":       sleep,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	started := tagsOf(t, events, contracts.MonitorEventStarted, 4)
+	if !before(started, "db", "queue") || !before(started, "queue", "worker-1") || !before(started, "worker-1", "worker-2") {
+		t.Fatalf("bad start order %v", started)
+	}
+
+	monitor.StopAll()
+
+	stopped := tagsOf(t, events, contracts.MonitorEventStopped, 4)
+	if !before(stopped, "worker-2", "worker-1") || !before(stopped, "worker-1", "queue") || !before(stopped, "queue", "db") {
+		t.Fatalf("bad stop order %v", stopped)
+	}
+}
+
+func TestDependencyCycleUnix(t *testing.T) {
+	monitor := procMon.New()
+
+	err := monitor.SpawnAll(map[string]contracts.ProcessTemplate{
+		"a": {Executable: "sleep", Args: []string{"30"}, Requires: []string{"b"}},
+		"b": {Executable: "sleep", Args: []string{"30"}, After: []string{"c"}},
+		"c": {Executable: "sleep", Args: []string{"30"}, After: []string{"a"}},
+	})
+	if !errors.Is(err, contracts.ErrDependencyCycle) {
+		t.Fatalf("expected ErrDependencyCycle, got %v", err)
+	}
+
+	if len(monitor.GetAllTags()) != 0 {
+		t.Fatalf("nothing should be spawned, got %v", monitor.GetAllTags())
+	}
+
+	err = monitor.SpawnAll(map[string]contracts.ProcessTemplate{
+		"a": {Executable: "sleep", Args: []string{"30"}, Requires: []string{"nope"}},
+	})
+	if !errors.Is(err, contracts.ErrDependencyMissing) {
+		t.Fatalf("expected ErrDependencyMissing, got %v", err)
+	}
+}
+
+func TestDependencyFailedUnix(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	monitor.SpawnAll(map[string]contracts.ProcessTemplate{
+		"db":    {Executable: "/does/not/exist"},
+		"queue": {Executable: "sleep", Args: []string{"30"}, Requires: []string{"db"}},
+		"other": {Executable: "sleep", Args: []string{"30"}, After: []string{"db"}},
+	})
+
+	if monitor.GetProcess("queue").IsRunning() {
+		t.Fatalf("queue started without db")
+	}
+
+	if !monitor.GetProcess("other").IsRunning() {
+		t.Fatalf("other only starts after db, it should not care that db failed")
+	}
+}
+
+func tagsOf(t *testing.T, events <-chan contracts.MonitorEvent, eventType contracts.MonitorEventType, count int) []string {
+	tags := []string{}
+	timeout := time.After(5 * time.Second)
+
+	for len(tags) < count {
+		select {
+		case event := <-events:
+			if event.Type == eventType {
+				tags = append(tags, event.Tag)
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s events, got %v", eventType, tags)
+		}
+	}
+
+	return tags
+}
+
+func before(tags []string, first string, second string) bool {
+	firstIndex, secondIndex := -1, -1
+	for i, tag := range tags {
+		if tag == first {
+			firstIndex = i
+		}
+		if tag == second {
+			secondIndex = i
+		}
+	}
+
+	return firstIndex >= 0 && secondIndex >= 0 && firstIndex < secondIndex
+}
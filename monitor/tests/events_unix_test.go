@@ -0,0 +1,58 @@
+// +build !windows
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"github.com/codemodify/systemkit-processes/helpers"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestEventsUnix(t *testing.T) {
+	const logID = "TestEventsUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	events, unsubscribe := monitor.Events()
+	defer unsubscribe()
+
+	monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "exit 2"},
+	}, "exit-2")
+
+	expected := []contracts.MonitorEventType{
+		contracts.MonitorEventSpawned,
+		contracts.MonitorEventStarted,
+		contracts.MonitorEventExited,
+		contracts.MonitorEventRemoved,
+	}
+
+	for _, expectedType := range expected {
+		select {
+		case event := <-events:
+			logging.Debugf("%s: %s", logID, helpers.AsJSONString(event))
+
+			if event.Type != expectedType || event.Tag != "exit-2" {
+				t.Fatalf("expected %s, got %s", expectedType, helpers.AsJSONString(event))
+			}
+
+			if event.Type == contracts.MonitorEventExited && event.ExitStatus.Code != 2 {
+				t.Fatalf("bad exit status: %+v", event.ExitStatus)
+			}
+
+			if event.Type == contracts.MonitorEventExited {
+				monitor.RemoveFromMonitor("exit-2")
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected %s, got nothing", expectedType)
+		}
+	}
+}
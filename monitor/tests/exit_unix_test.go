@@ -0,0 +1,48 @@
+// +build !windows
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestExitStatusUnix(t *testing.T) {
+	const logID = "TestExitStatusUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "sleep 0.2; exit 7"},
+	})
+
+	exitStatuses := make(chan contracts.ExitStatus, 2)
+	for i := 0; i < 2; i++ {
+		monitor.GetProcess(processTag).OnStop(func(params interface{}, exitStatus contracts.ExitStatus) {
+			exitStatuses <- exitStatus
+		}, nil)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case exitStatus := <-exitStatuses:
+			if !exitStatus.Known || exitStatus.Code != 7 {
+				t.Fatalf("bad exit status: %+v", exitStatus)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatal("exit was not delivered to every delegate")
+		}
+	}
+
+	if monitor.GetProcess(processTag).ExitCode() != 7 {
+		t.Fatalf("bad exit code: %d", monitor.GetProcess(processTag).ExitCode())
+	}
+}
@@ -0,0 +1,248 @@
+// +build !windows
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestHealthReadinessUnix(t *testing.T) {
+	const logID = "TestHealthReadinessUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	spawnedAt := time.Now()
+	err := monitor.SpawnAll(map[string]contracts.ProcessTemplate{
+		"db": {
+			Executable: "sh",
+			Args:       []string{"-c", "sleep 0.5; echo ready; sleep 30"},
+			Readiness: contracts.HealthCheck{
+				Type:     contracts.HealthCheckOutput,
+				Pattern:  "^ready$",
+				Interval: 100 * time.Millisecond,
+			},
+		},
+		"web": {
+			Executable: "sleep",
+			Args:       []string{"30"},
+			Requires:   []string{"db"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if time.Since(spawnedAt) < 500*time.Millisecond {
+		t.Fatalf("web started before db was ready")
+	}
+
+	if health := monitor.GetHealth("db"); !health.Ready || health.Status != contracts.HealthHealthy {
+		t.Fatalf("bad db health %+v", health)
+	}
+
+	// no checks, ready once running
+	if err := monitor.WaitReady(context.Background(), "web"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if health := monitor.GetHealth("missing"); health.Status != contracts.HealthUnknown || health.Ready {
+		t.Fatalf("bad health for a missing tag %+v", health)
+	}
+}
+
+func TestHealthNeverReadyUnix(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	address := listener.Addr().String()
+	listener.Close() // nothing listens
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	err = monitor.SpawnAll(map[string]contracts.ProcessTemplate{
+		"db": {
+			Executable: "sleep",
+			Args:       []string{"30"},
+			Readiness: contracts.HealthCheck{
+				Type:         contracts.HealthCheckTCP,
+				Address:      address,
+				Interval:     100 * time.Millisecond,
+				StartTimeout: 500 * time.Millisecond,
+			},
+		},
+		"web": {
+			Executable: "sleep",
+			Args:       []string{"30"},
+			Requires:   []string{"db"},
+		},
+	})
+	if !errors.Is(err, contracts.ErrDependencyFailed) || !strings.Contains(err.Error(), "ErrNotReady") {
+		t.Fatalf("expected ErrDependencyFailed for ErrNotReady, got %v", err)
+	}
+
+	if monitor.GetProcess("web").IsRunning() {
+		t.Fatalf("web should not run")
+	}
+
+	health := monitor.GetHealth("db")
+	if health.Status != contracts.HealthStarting || health.ConsecutiveFailures == 0 || len(health.LastError) == 0 {
+		t.Fatalf("bad db health %+v", health)
+	}
+}
+
+func TestHealthLivenessRestartUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "health")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	aliveFile := filepath.Join(dir, "alive")
+	if err := ioutil.WriteFile(aliveFile, nil, 0644); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	events, unsubscribe := monitor.Events()
+	defer unsubscribe()
+
+	err = monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		Liveness: contracts.HealthCheck{
+			Type:             contracts.HealthCheckExec,
+			Command:          []string{"test", "-e", aliveFile},
+			Interval:         100 * time.Millisecond,
+			FailureThreshold: 2,
+			RestartOnFailure: true,
+		},
+	}, "web")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	firstPID := monitor.GetProcess("web").Details().ProcessID
+
+	time.Sleep(300 * time.Millisecond)
+	if health := monitor.GetHealth("web"); health.Status != contracts.HealthHealthy || health.ConsecutiveFailures != 0 {
+		t.Fatalf("bad health %+v", health)
+	}
+
+	os.Remove(aliveFile)
+
+	unhealthy := false
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Type == contracts.MonitorEventHealthChanged && event.Health.Status == contracts.HealthUnhealthy {
+				unhealthy = true
+			}
+
+			if event.Type == contracts.MonitorEventRestarted {
+				if !unhealthy {
+					t.Fatalf("restarted before unhealthy")
+				}
+
+				if event.ProcessID == firstPID {
+					t.Fatalf("expected a new PID")
+				}
+
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no restart after liveness failures")
+		}
+	}
+}
+
+func TestHealthLivenessRestartLimitUnix(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	// never alive, restarted once then left stopped
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		Liveness: contracts.HealthCheck{
+			Type:             contracts.HealthCheckExec,
+			Command:          []string{"false"},
+			Interval:         100 * time.Millisecond,
+			FailureThreshold: 2,
+			RestartOnFailure: true,
+		},
+		RestartPolicy: contracts.RestartPolicy{
+			MaxRestarts: 1,
+			Window:      1 * time.Minute,
+		},
+	}, "web")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	time.Sleep(3 * time.Second)
+
+	restartState := monitor.GetRestartState("web")
+	if restartState.Restarts != 1 || !restartState.GaveUp {
+		t.Fatalf("expected 1 restart then give up, got %+v", restartState)
+	}
+
+	if monitor.GetProcess("web").IsRunning() {
+		t.Fatalf("expected stopped after giving up")
+	}
+}
+
+func TestHealthInvalidCheckUnix(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	invalid := contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		Liveness: contracts.HealthCheck{
+			Type:    contracts.HealthCheckOutput,
+			Pattern: "(",
+		},
+	}
+
+	if err := monitor.SpawnWithTag(invalid, "spawn"); !errors.Is(err, contracts.ErrInvalidHealthCheck) {
+		t.Fatalf("expected ErrInvalidHealthCheck, got %v", err)
+	}
+
+	err := monitor.SpawnAll(map[string]contracts.ProcessTemplate{
+		"valid":   {Executable: "sleep", Args: []string{"30"}},
+		"invalid": invalid,
+	})
+	if !errors.Is(err, contracts.ErrInvalidHealthCheck) {
+		t.Fatalf("expected ErrInvalidHealthCheck, got %v", err)
+	}
+
+	if _, err := monitor.Reload(map[string]contracts.ProcessTemplate{"reload": invalid}); !errors.Is(err, contracts.ErrReloadFailed) {
+		t.Fatalf("expected ErrReloadFailed, got %v", err)
+	}
+
+	// nothing spawned
+	if tags := monitor.GetAllTags(); len(tags) != 0 {
+		t.Fatalf("expected no tags, got %v", tags)
+	}
+}
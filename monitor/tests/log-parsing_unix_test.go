@@ -0,0 +1,114 @@
+// +build !windows
+
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestLogParsingJSONUnix(t *testing.T) {
+	const logID = "TestLogParsingJSONUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	records := spawnAndParse(t, "json-service", contracts.LogFormatJSON, `
+echo '{"level":"warn","msg":"disk almost full","time":"2021-01-02T03:04:05Z","free":42}'
+echo '{"level":50,"message":"boom"}' >&2
+echo 'not json'
+`)
+
+	if len(records[contracts.OutputStreamStdOut]) != 2 || len(records[contracts.OutputStreamStdErr]) != 1 {
+		t.Fatalf("expected 2 STDOUT and 1 STDERR records, got %v", records)
+	}
+
+	warning := records[contracts.OutputStreamStdOut][0]
+	if !warning.Parsed ||
+		warning.Tag != "json-service" ||
+		warning.Level != logging.TypeWarning ||
+		warning.Message != "disk almost full" ||
+		warning.Time.Year() != 2021 ||
+		warning.Fields["free"] != float64(42) ||
+		len(warning.Fields) != 1 {
+		t.Fatalf("bad record %+v", warning)
+	}
+
+	if notJSON := records[contracts.OutputStreamStdOut][1]; notJSON.Parsed || notJSON.Message != "not json" || notJSON.Level != logging.TypeInfo {
+		t.Fatalf("bad record %+v", notJSON)
+	}
+
+	if boom := records[contracts.OutputStreamStdErr][0]; boom.Level != logging.TypeError || boom.Message != "boom" || boom.Stream != contracts.OutputStreamStdErr {
+		t.Fatalf("bad record %+v", boom)
+	}
+}
+
+func TestLogParsingLogfmtUnix(t *testing.T) {
+	records := spawnAndParse(t, "logfmt-service", contracts.LogFormatLogfmt, `
+echo 'ts=1609556645.5 lvl=error msg="request \"failed\"" path=/api retry'
+echo 'listening on port=8080'
+echo 'user=bob action=login'
+`)
+
+	if len(records[contracts.OutputStreamStdOut]) != 3 {
+		t.Fatalf("expected 3 records, got %v", records)
+	}
+
+	record := records[contracts.OutputStreamStdOut][0]
+	if !record.Parsed ||
+		record.Level != logging.TypeError ||
+		record.Message != `request "failed"` ||
+		record.Time.Unix() != 1609556645 ||
+		record.Fields["path"] != "/api" ||
+		record.Fields["retry"] != true {
+		t.Fatalf("bad record %+v", record)
+	}
+
+	// words without a level or a message are not logfmt
+	if plain := records[contracts.OutputStreamStdOut][1]; plain.Parsed || plain.Message != "listening on port=8080" {
+		t.Fatalf("bad record %+v", plain)
+	}
+
+	if pairs := records[contracts.OutputStreamStdOut][2]; !pairs.Parsed || pairs.Fields["user"] != "bob" || pairs.Fields["action"] != "login" {
+		t.Fatalf("bad record %+v", pairs)
+	}
+}
+
+func spawnAndParse(t *testing.T, tag string, format contracts.LogFormat, script string) map[contracts.OutputStream][]contracts.LogRecord {
+	recordsSync := sync.Mutex{}
+	records := map[contracts.OutputStream][]contracts.LogRecord{}
+
+	monitor := procMon.New()
+	monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", script},
+		LogParsing: contracts.LogParsing{
+			Format: format,
+			OnRecord: func(params interface{}, logRecord contracts.LogRecord) {
+				recordsSync.Lock()
+				defer recordsSync.Unlock()
+
+				records[logRecord.Stream] = append(records[logRecord.Stream], logRecord)
+			},
+		},
+	}, tag)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := monitor.GetProcess(tag).Wait(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond) // let the pumps catch up
+
+	recordsSync.Lock()
+	defer recordsSync.Unlock()
+
+	return records
+}
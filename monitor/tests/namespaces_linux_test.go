@@ -0,0 +1,152 @@
+// +build linux
+
+package tests
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestNamespacesLinux(t *testing.T) {
+	const logID = "TestNamespacesLinux"
+
+	logging.Debugf("%s: START", logID)
+
+	if os.Geteuid() != 0 {
+		t.Skip("needs root")
+	}
+
+	hostname, _ := os.Hostname()
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	// PID 1, its own hostname, only a loopback interface
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "echo $$; cat /proc/sys/kernel/hostname; grep -c : /proc/net/dev; sleep 30"},
+		Namespaces: contracts.Namespaces{
+			PID:      true,
+			Mount:    true,
+			Network:  true,
+			UTS:      true,
+			IPC:      true,
+			Hostname: "sandbox",
+		},
+	}, "sandbox")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("sandbox")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rp.Output(0)) < 3 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	expected := "[stdout 1][stdout sandbox][stdout 1]"
+	if output := linesAsString(rp.Output(0)); output != expected {
+		t.Fatalf("expected %s, got %s", expected, output)
+	}
+
+	if after, _ := os.Hostname(); after != hostname {
+		t.Fatalf("hostname of the host changed from %s to %s", hostname, after)
+	}
+
+	// the host still sees its own PID
+	if details := rp.Details(); details.ProcessID <= 1 || details.ParentProcessID != os.Getpid() {
+		t.Fatalf("bad host PID %d, parent %d", details.ProcessID, details.ParentProcessID)
+	}
+}
+
+func TestUserNamespaceLinux(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	// root inside is the caller outside
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable:       "sh",
+		Args:             []string{"-c", "id -u; id -g; sleep 30"},
+		WorkingDirectory: "/",
+		Namespaces: contracts.Namespaces{
+			User: true,
+			UTS:  true,
+		},
+	}, "user")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("user")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rp.Output(0)) < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	expected := "[stdout 0][stdout 0]"
+	if output := linesAsString(rp.Output(0)); output != expected {
+		t.Fatalf("expected %s, got %s", expected, output)
+	}
+
+	if details := rp.Details(); details.UserID != os.Geteuid() {
+		t.Fatalf("expected user %d outside, got %d", os.Geteuid(), details.UserID)
+	}
+
+	hostname := contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		Namespaces: contracts.Namespaces{
+			User:     true,
+			UTS:      true,
+			Hostname: "sandbox",
+		},
+	}
+
+	// a hostname needs root
+	if os.Geteuid() != 0 {
+		if err := monitor.SpawnWithTag(hostname, "hostname"); !errors.Is(err, contracts.ErrNamespacesNotPermitted) {
+			t.Fatalf("expected ErrNamespacesNotPermitted, got %v", err)
+		}
+
+		return
+	}
+
+	// root maps someone else
+	err = monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable:       "sh",
+		Args:             []string{"-c", "id -u; sleep 30"},
+		WorkingDirectory: "/",
+		Namespaces: contracts.Namespaces{
+			User:        true,
+			UIDMappings: []contracts.IDMapping{{ContainerID: 0, HostID: 65534, Size: 1}},
+			GIDMappings: []contracts.IDMapping{{ContainerID: 0, HostID: 65534, Size: 1}},
+		},
+	}, "mapped")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mapped := monitor.GetProcess("mapped")
+
+	deadline = time.Now().Add(3 * time.Second)
+	for len(mapped.Output(0)) < 1 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if output := linesAsString(mapped.Output(0)); output != "[stdout 0]" {
+		t.Fatalf("expected [stdout 0], got %s", output)
+	}
+
+	if details := mapped.Details(); details.UserID != 65534 || details.GroupID != 65534 {
+		t.Fatalf("bad user and group outside %d:%d", details.UserID, details.GroupID)
+	}
+}
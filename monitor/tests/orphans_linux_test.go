@@ -0,0 +1,182 @@
+// +build linux
+
+package tests
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+// processState - the state letter of `/proc/<pid>/stat` and the parent, `X` if gone
+func processState(pid int) (string, int) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return "X", 0
+	}
+
+	fields := strings.Fields(string(data[strings.LastIndex(string(data), ")")+1:]))
+	ppid, _ := strconv.Atoi(fields[1])
+
+	return fields[0], ppid
+}
+
+// TestParentDeathSignalHelperLinux - the supervisor that dies, started by `TestParentDeathSignalLinux`
+func TestParentDeathSignalHelperLinux(t *testing.T) {
+	if os.Getenv("PARENT_DEATH_SIGNAL_TEST_HELPER") != "1" {
+		t.Skip("started by TestParentDeathSignalLinux")
+	}
+
+	monitor := procMon.New()
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable:        "sleep",
+		Args:              []string{"30"},
+		ParentDeathSignal: "SIGKILL",
+	}, "sleep")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	fmt.Printf("CHILD-PID=%d\n", monitor.GetProcess("sleep").Details().ProcessID)
+
+	time.Sleep(30 * time.Second)
+}
+
+func TestParentDeathSignalLinux(t *testing.T) {
+	const logID = "TestParentDeathSignalLinux"
+
+	logging.Debugf("%s: START", logID)
+
+	helper := exec.Command(os.Args[0], "-test.run=^TestParentDeathSignalHelperLinux$")
+	helper.Env = append(os.Environ(), "PARENT_DEATH_SIGNAL_TEST_HELPER=1")
+	stdOut, _ := helper.StdoutPipe()
+	if err := helper.Start(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer helper.Process.Kill()
+
+	childPID := 0
+	scanner := bufio.NewScanner(stdOut)
+	for childPID == 0 && scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "CHILD-PID=") {
+			childPID, _ = strconv.Atoi(strings.TrimPrefix(line, "CHILD-PID="))
+		}
+	}
+	if childPID <= 0 {
+		t.Fatalf("no child PID from the helper")
+	}
+
+	if state, _ := processState(childPID); state == "X" || state == "Z" {
+		t.Fatalf("expected the child running, got %s", state)
+	}
+
+	// the supervisor dies without stopping anything
+	helper.Process.Kill()
+	helper.Wait()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if state, _ := processState(childPID); state == "X" || state == "Z" {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatalf("child %d survived its supervisor", childPID)
+}
+
+func TestSubreaperLinux(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	if err := monitor.EnableSubreaper(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// the inner shell exits and leaves its background sleep behind
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable:      "sh",
+		Args:            []string{"-c", `sh -c 'sleep 1 & echo $!'; sleep 30`},
+		NewProcessGroup: true,
+	}, "orphans")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("orphans")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rp.Output(0)) < 1 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	lines := rp.Output(0)
+	if len(lines) < 1 {
+		t.Fatalf("no PID of the orphan")
+	}
+
+	orphanPID, _ := strconv.Atoi(strings.TrimSpace(string(lines[0].Data)))
+
+	// reparented to us, not to init
+	deadline = time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, ppid := processState(orphanPID); ppid == os.Getpid() {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if state, ppid := processState(orphanPID); ppid != os.Getpid() {
+		t.Fatalf("expected %d adopted, got state %s, parent %d", orphanPID, state, ppid)
+	}
+
+	// then reaped, not left a zombie
+	deadline = time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if state, _ := processState(orphanPID); state == "X" {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if state, _ := processState(orphanPID); state != "X" {
+		t.Fatalf("expected %d reaped, got state %s", orphanPID, state)
+	}
+
+	// the monitored process is still ours to wait for
+	if !rp.IsRunning() {
+		t.Fatalf("expected running")
+	}
+}
+
+func TestSubreaperLeavesOtherChildrenLinux(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	if err := monitor.EnableSubreaper(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// started by the host program, not by the monitor
+	cmd := exec.Command("sh", "-c", "exit 3")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// SIGCHLD and a few scans
+	time.Sleep(2500 * time.Millisecond)
+
+	cmd.Wait()
+	if exitCode := cmd.ProcessState.ExitCode(); exitCode != 3 {
+		t.Fatalf("expected exit code 3 for the owner, got %d", exitCode)
+	}
+}
@@ -0,0 +1,213 @@
+// +build !windows
+
+package tests
+
+import (
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+	"github.com/codemodify/systemkit-processes/output"
+)
+
+func TestOutputFileUnix(t *testing.T) {
+	const logID = "TestOutputFileUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	folder := t.TempDir()
+	stdOutPath := filepath.Join(folder, "out.log")
+	stdErrPath := filepath.Join(folder, "err.log")
+
+	// not rotated from `out.log`, retention leaves it alone
+	siblingPath := stdOutPath + ".err"
+	ioutil.WriteFile(siblingPath, []byte("sibling\n"), 0644)
+
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "read go; for i in 1 2 3 4 5 6 7 8 9; do echo line-$i; done; echo oops >&2"},
+		StdinOpen:  true,
+		StdOutFile: contracts.OutputFile{
+			Path:            stdOutPath,
+			MaxSize:         40, // 2 lines of 16 bytes per file
+			MaxFiles:        2,
+			Compress:        true,
+			TimestampFormat: "15:04:05",
+		},
+	})
+	rp := monitor.GetProcess(processTag)
+
+	// attached through the hook
+	stdErrFile, err := output.NewFile(contracts.OutputFile{Path: stdErrPath})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	rp.OnStdErr(stdErrFile.OnOutput, nil)
+
+	rp.Stdin().Write([]byte("go\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	rp.Wait(ctx)
+	time.Sleep(100 * time.Millisecond) // let the pumps catch up
+	stdErrFile.Close()
+
+	current, _ := ioutil.ReadFile(stdOutPath)
+	if lines := strings.Split(strings.TrimSpace(string(current)), "\n"); len(lines) != 1 || !strings.HasSuffix(lines[0], " line-9") {
+		t.Fatalf("bad current file: %q", string(current))
+	}
+
+	// compressed in the background
+	rotated := []string{}
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		all, _ := filepath.Glob(stdOutPath + ".*")
+		rotated, _ = filepath.Glob(stdOutPath + ".*.gz")
+		if len(rotated) == 2 && len(all) == 3 {
+			break
+		}
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("expected 2 rotated files, got %v", rotated)
+	}
+
+	if content := readGzip(t, rotated[1]); !strings.Contains(content, " line-7\n") || !strings.Contains(content, " line-8\n") {
+		t.Fatalf("bad rotated file: %q", content)
+	}
+
+	if content, _ := ioutil.ReadFile(stdErrPath); string(content) != "oops\n" {
+		t.Fatalf("bad STDERR file: %q", string(content))
+	}
+
+	if _, err := os.Stat(siblingPath); err != nil {
+		t.Fatalf("expected %s kept, %s", siblingPath, err.Error())
+	}
+}
+
+func readGzip(t *testing.T, path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return string(content)
+}
+
+// openCount - descriptors of this process open on `path`, -1 without `/proc`
+func openCount(path string) int {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+
+	count := 0
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && target == path {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestOutputFileClosedUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if openCount(path) < 0 {
+		t.Skip("needs /proc")
+	}
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	template := contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "echo one"},
+		StdOutFile: contracts.OutputFile{Path: path},
+	}
+
+	// re-spawning the tag closes the file of the replaced process
+	for i := 0; i < 3; i++ {
+		if err := monitor.SpawnWithTag(template, "file"); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		monitor.GetProcess("file").Wait(ctx)
+		cancel()
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for openCount(path) > 1 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if count := openCount(path); count != 1 {
+		t.Fatalf("expected the file open once, got %d", count)
+	}
+
+	monitor.RemoveFromMonitor("file")
+
+	deadline = time.Now().Add(3 * time.Second)
+	for openCount(path) > 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if count := openCount(path); count != 0 {
+		t.Fatalf("expected the file closed, open %d times", count)
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if string(data) != "one\none\none\n" {
+		t.Fatalf("expected three lines, got %q", string(data))
+	}
+}
+
+func TestOutputFileCompressOnCloseUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	sink, err := output.NewFile(contracts.OutputFile{
+		Path:     path,
+		MaxSize:  1, // a file per line
+		MaxFiles: 3,
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		sink.OnOutput(nil, []byte(strings.Repeat("x", 64*1024)))
+	}
+
+	// waits for the compressions and the retention
+	sink.Close()
+
+	all, _ := filepath.Glob(path + ".*")
+	rotated, _ := filepath.Glob(path + ".*.gz")
+	if len(rotated) != 3 || len(all) != 3 {
+		t.Fatalf("expected 3 compressed files, got %v", all)
+	}
+
+	if content := readGzip(t, rotated[2]); len(content) != 64*1024+1 {
+		t.Fatalf("bad rotated file, %d bytes", len(content))
+	}
+}
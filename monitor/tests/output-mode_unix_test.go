@@ -0,0 +1,82 @@
+// +build !windows
+
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestOutputLinesUnix(t *testing.T) {
+	const logID = "TestOutputLinesUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	// a line longer than the reader buffer, one longer than the limit and one without a line break
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable:    "sh",
+		Args:          []string{"-c", "head -c 10000 /dev/zero | tr '\\0' x; echo; head -c 30000 /dev/zero | tr '\\0' y; printf '\\r\\nshort\\r\\nlast'"},
+		MaxLineLength: 20000,
+	})
+	rp := monitor.GetProcess(processTag)
+
+	waitOutput(t, rp)
+
+	output := rp.Output(0)
+	if len(output) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(output))
+	}
+
+	if len(output[0].Data) != 10000 || output[0].Truncated || len(bytes.Trim(output[0].Data, "x")) != 0 {
+		t.Fatalf("bad long line, %d bytes", len(output[0].Data))
+	}
+
+	if len(output[1].Data) != 20000 || !output[1].Truncated {
+		t.Fatalf("bad truncated line, %d bytes, truncated %v", len(output[1].Data), output[1].Truncated)
+	}
+
+	if string(output[2].Data) != "short" || string(output[3].Data) != "last" {
+		t.Fatalf("bad lines [%q] [%q]", string(output[2].Data), string(output[3].Data))
+	}
+}
+
+func TestOutputRawUnix(t *testing.T) {
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "printf",
+		Args:       []string{"a\\nb\\000\\r\\nc"},
+		OutputMode: contracts.OutputModeRaw,
+	})
+	rp := monitor.GetProcess(processTag)
+
+	waitOutput(t, rp)
+
+	written := []byte{}
+	for _, chunk := range rp.Output(0) {
+		written = append(written, chunk.Data...)
+	}
+
+	if !bytes.Equal(written, []byte("a\nb\x00\r\nc")) {
+		t.Fatalf("bad output %q", written)
+	}
+}
+
+func waitOutput(t *testing.T, rp contracts.RuningProcess) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := rp.Wait(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond) // let the pumps catch up
+}
@@ -0,0 +1,113 @@
+// +build !windows
+
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestOutputSubscribersUnix(t *testing.T) {
+	const logID = "TestOutputSubscribersUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "read go; for i in 1 2 3 4 5; do echo out-$i; done; echo err-1 >&2"},
+		StdinOpen:  true,
+	})
+	rp := monitor.GetProcess(processTag)
+
+	first, unsubscribeFirst := rp.SubscribeOutput(contracts.OutputSubscription{
+		Stream:       contracts.OutputStreamStdOut,
+		Backpressure: contracts.OutputBackpressureBlock,
+		BufferSize:   1,
+	})
+	defer unsubscribeFirst()
+
+	second, unsubscribeSecond := rp.SubscribeOutput(contracts.OutputSubscription{
+		Stream:       contracts.OutputStreamStdOut,
+		Backpressure: contracts.OutputBackpressureBlock,
+	})
+	defer unsubscribeSecond()
+
+	newest, unsubscribeNewest := rp.SubscribeOutput(contracts.OutputSubscription{
+		Stream:       contracts.OutputStreamStdOut,
+		Backpressure: contracts.OutputBackpressureDropNewest,
+		BufferSize:   2,
+	})
+
+	oldest, unsubscribeOldest := rp.SubscribeOutput(contracts.OutputSubscription{
+		Stream:       contracts.OutputStreamStdOut,
+		Backpressure: contracts.OutputBackpressureDropOldest,
+		BufferSize:   2,
+	})
+
+	stdErr, unsubscribeStdErr := rp.SubscribeOutput(contracts.OutputSubscription{
+		Stream: contracts.OutputStreamStdErr,
+	})
+	defer unsubscribeStdErr()
+
+	rp.Stdin().Write([]byte("go\n"))
+
+	// both blocking subscribers get every line
+	for _, expected := range []string{"out-1", "out-2", "out-3", "out-4", "out-5"} {
+		if line := receiveLine(t, first); string(line.Data) != expected {
+			t.Fatalf("first: expected %s, got %s", expected, string(line.Data))
+		}
+		if line := receiveLine(t, second); string(line.Data) != expected {
+			t.Fatalf("second: expected %s, got %s", expected, string(line.Data))
+		}
+	}
+
+	if line := receiveLine(t, stdErr); string(line.Data) != "err-1" {
+		t.Fatalf("stderr: expected err-1, got %s", string(line.Data))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	rp.Wait(ctx)
+
+	// nobody read these while the output was produced
+	unsubscribeNewest()
+	if lines := drainLines(newest); lines != "out-1,out-2" {
+		t.Fatalf("drop-newest kept %s", lines)
+	}
+
+	unsubscribeOldest()
+	if lines := drainLines(oldest); lines != "out-4,out-5" {
+		t.Fatalf("drop-oldest kept %s", lines)
+	}
+}
+
+func receiveLine(t *testing.T, lines <-chan contracts.OutputLine) contracts.OutputLine {
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for a line")
+	}
+
+	return contracts.OutputLine{}
+}
+
+func drainLines(lines <-chan contracts.OutputLine) string {
+	result := ""
+	for line := range lines {
+		if len(result) > 0 {
+			result += ","
+		}
+		result += string(line.Data)
+	}
+
+	return result
+}
@@ -0,0 +1,143 @@
+// +build !windows
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestOutputUnix(t *testing.T) {
+	const logID = "TestOutputUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable:   "sh",
+		Args:         []string{"-c", "for i in 1 2 3 4 5; do echo out-$i; done; sleep 0.2; echo err-1 >&2"},
+		OutputBuffer: contracts.OutputBufferSize{Lines: 4},
+	})
+	rp := monitor.GetProcess(processTag)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := rp.Wait(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond) // let the pumps catch up
+
+	// nobody was reading, the last 4 lines are retained
+	output := rp.Output(0)
+	if len(output) != 4 ||
+		string(output[0].Data) != "out-3" ||
+		string(output[3].Data) != "err-1" ||
+		output[3].Stream != contracts.OutputStreamStdErr {
+		t.Fatalf("bad output: %s", linesAsString(output))
+	}
+
+	if tail := rp.Output(2); len(tail) != 2 || string(tail[0].Data) != "out-5" {
+		t.Fatalf("bad tail: %s", linesAsString(tail))
+	}
+
+	// late readers still get what the run wrote
+	lines := []string{}
+	rp.OnStdOut(func(params interface{}, outputData []byte) {
+		lines = append(lines, string(outputData))
+	}, nil)
+	if len(lines) != 3 || lines[2] != "out-5" {
+		t.Fatalf("bad replay: %v", lines)
+	}
+}
+
+func TestOutputReaderAddsReaderUnix(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "sleep 0.2; echo one; sleep 0.2; echo two"},
+	})
+	rp := monitor.GetProcess(processTag)
+
+	// a reader that adds a reader from inside the callback
+	added := []string{}
+	addedSync := &sync.Mutex{}
+	once := &sync.Once{}
+	rp.OnStdOut(func(params interface{}, outputData []byte) {
+		once.Do(func() {
+			rp.OnStdOut(func(params interface{}, outputData []byte) {
+				addedSync.Lock()
+				defer addedSync.Unlock()
+
+				added = append(added, string(outputData))
+			}, nil)
+		})
+	}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := rp.Wait(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond) // let the pumps catch up
+
+	addedSync.Lock()
+	defer addedSync.Unlock()
+
+	if fmt.Sprint(added) != "[one two]" {
+		t.Fatalf("expected [one two], got %v", added)
+	}
+}
+
+func TestFollowOutputUnix(t *testing.T) {
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "echo first; sleep 0.3; echo second; sleep 0.3; echo third; sleep 5"},
+	})
+	defer monitor.Stop(processTag)
+
+	time.Sleep(150 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	follow := monitor.GetProcess(processTag).FollowOutput(ctx, 1)
+
+	for _, expected := range []string{"first", "second", "third"} {
+		select {
+		case line := <-follow:
+			if string(line.Data) != expected {
+				t.Fatalf("expected %s, got %s", expected, string(line.Data))
+			}
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for %s", expected)
+		}
+	}
+
+	cancel()
+	for range follow {
+	}
+}
+
+func linesAsString(lines []contracts.OutputLine) string {
+	result := ""
+	for _, line := range lines {
+		result += fmt.Sprintf("[%s %s]", line.Stream, string(line.Data))
+	}
+
+	return result
+}
@@ -0,0 +1,73 @@
+// +build !windows
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"github.com/codemodify/systemkit-processes/find"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestProcessGroupUnix(t *testing.T) {
+	const logID = "TestProcessGroupUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	testStopLeavesNoChildren(t, contracts.ProcessTemplate{
+		Executable:      "sh",
+		Args:            []string{"-c", "sleep 30 & sleep 30 & wait"},
+		NewProcessGroup: true,
+	})
+}
+
+func TestKillTreeUnix(t *testing.T) {
+	const logID = "TestKillTreeUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	// background jobs of a non-interactive `sh` ignore SIGINT, without `KillTree` they outlive `sh`
+	testStopLeavesNoChildren(t, contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "sleep 30 & wait"},
+		KillTree:   true,
+	})
+}
+
+func testStopLeavesNoChildren(t *testing.T, processTemplate contracts.ProcessTemplate) {
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(processTemplate)
+	time.Sleep(200 * time.Millisecond)
+
+	pid := monitor.GetProcess(processTag).Details().ProcessID
+
+	allProcesses, err := find.AllProcesses()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	children := []contracts.RuningProcess{}
+	for _, p := range allProcesses {
+		if p.Details().ParentProcessID == pid {
+			children = append(children, p)
+		}
+	}
+
+	if len(children) == 0 {
+		t.Fatal("should have children")
+	}
+
+	monitor.StopWithTimeout(processTag, 1, 500*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+
+	for _, child := range children {
+		if child.IsRunning() {
+			t.Fatalf("child [%d] survived", child.Details().ProcessID)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// +build linux
+
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestPTYLinux(t *testing.T) {
+	const logID = "TestPTYLinux"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	processTag, err := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "[ -t 1 ] && echo is-a-tty; stty size; read x; stty size; echo got-$x"},
+		PTY:        true,
+		PTYSize:    contracts.PTYSize{Rows: 30, Cols: 100},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	rp := monitor.GetProcess(processTag)
+
+	outputSync := sync.Mutex{}
+	output := []string{}
+	rp.OnStdOut(func(params interface{}, outputData []byte) {
+		outputSync.Lock()
+		defer outputSync.Unlock()
+
+		output = append(output, strings.TrimSpace(string(outputData)))
+	}, nil)
+
+	time.Sleep(200 * time.Millisecond)
+
+	if err := rp.ResizePTY(contracts.PTYSize{Rows: 40, Cols: 120}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	rp.Stdin().Write([]byte("hello\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := rp.Wait(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond) // let the reader catch up
+
+	outputSync.Lock()
+	defer outputSync.Unlock()
+
+	joined := strings.Join(output, ",")
+	for _, expected := range []string{"is-a-tty", "30 100", "40 120", "got-hello"} {
+		if !strings.Contains(joined, expected) {
+			t.Fatalf("expected [%s] in output: %v", expected, output)
+		}
+	}
+}
+
+func TestPTYStdinCloseLinux(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	// no newline at the end, closing STDIN must still end `cat`
+	processTag, err := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "cat",
+		StdinData:  []byte("partial"),
+		PTY:        true,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := monitor.GetProcess(processTag).Wait(ctx); err != nil {
+		t.Fatalf("expected cat to see EOF, got %s", err)
+	}
+}
@@ -0,0 +1,211 @@
+// +build linux
+
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+// runReaperHelper - reaping and signal forwarding are process wide, `helper` runs in a test binary of its own
+func runReaperHelper(t *testing.T, helper string) {
+	cmd := exec.Command(os.Args[0], "-test.v", "-test.run=^"+helper+"$")
+	cmd.Env = append(os.Environ(), "REAPER_TEST_HELPER=1")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(output), "--- PASS: "+helper) {
+		t.Fatalf("%s failed: %v\n%s", helper, err, output)
+	}
+}
+
+func TestReaperLinux(t *testing.T) {
+	const logID = "TestReaperLinux"
+
+	logging.Debugf("%s: START", logID)
+
+	runReaperHelper(t, "TestReaperHelperLinux")
+}
+
+func TestReaperForwardsSignalsLinux(t *testing.T) {
+	runReaperHelper(t, "TestReaperForwardsSignalsHelperLinux")
+}
+
+// TestReaperHelperLinux - started by `TestReaperLinux`
+func TestReaperHelperLinux(t *testing.T) {
+	if os.Getenv("REAPER_TEST_HELPER") != "1" {
+		t.Skip("started by TestReaperLinux")
+	}
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	if err := monitor.EnableReaper(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// every exit status gets to its process, whoever waits first
+	for i := 0; i < 5; i++ {
+		tag := fmt.Sprintf("exit-%d", i)
+		err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+			Executable: "sh",
+			Args:       []string{"-c", fmt.Sprintf("exit %d", 10+i)},
+		}, tag)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		exitStatus, err := monitor.GetProcess(tag).Wait(ctx)
+		cancel()
+
+		if err != nil || !exitStatus.Known || exitStatus.Code != 10+i {
+			t.Fatalf("%s: expected known exit %d, got %+v, %v", tag, 10+i, exitStatus, err)
+		}
+	}
+
+	// the stop of a process traced for its limits can be waited for by the reaper too
+	for i := 0; i < 5; i++ {
+		tag := fmt.Sprintf("limited-%d", i)
+		err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+			Executable: "sh",
+			Args:       []string{"-c", "ulimit -n"},
+			ResourceLimits: contracts.ResourceLimits{
+				OpenFiles: &contracts.ResourceLimit{Soft: 64, Hard: 128},
+			},
+		}, tag)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		exitStatus, err := monitor.GetProcess(tag).Wait(ctx)
+		cancel()
+
+		if err != nil || exitStatus.Code != 0 {
+			t.Fatalf("%s: expected a clean exit, got %+v, %v", tag, exitStatus, err)
+		}
+	}
+
+	// exec probes are waited for too
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		Readiness: contracts.HealthCheck{
+			Type:     contracts.HealthCheckExec,
+			Command:  []string{"sh", "-c", "exit 0"},
+			Interval: 100 * time.Millisecond,
+		},
+	}, "probed")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := monitor.WaitReady(ctx, "probed"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// orphans do not stay zombies
+	err = monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", `sh -c 'sleep 0.5 & echo $!'; sleep 30`},
+	}, "orphans")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("orphans")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rp.Output(0)) < 1 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(rp.Output(0)) < 1 {
+		t.Fatalf("no PID of the orphan")
+	}
+
+	orphanPID, _ := strconv.Atoi(strings.TrimSpace(string(rp.Output(0)[0].Data)))
+
+	deadline = time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if state, _ := processState(orphanPID); state == "X" {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	state, ppid := processState(orphanPID)
+	t.Fatalf("expected %d reaped, got state %s, parent %d", orphanPID, state, ppid)
+}
+
+// TestReaperForwardsSignalsHelperLinux - started by `TestReaperForwardsSignalsLinux`
+func TestReaperForwardsSignalsHelperLinux(t *testing.T) {
+	if os.Getenv("REAPER_TEST_HELPER") != "1" {
+		t.Skip("started by TestReaperForwardsSignalsLinux")
+	}
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	if err := monitor.EnableReaper(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", `trap 'echo TERM; exit 0' TERM; echo READY; while true; do sleep 0.1; done`},
+		RestartPolicy: contracts.RestartPolicy{
+			Mode: contracts.RestartAlways,
+		},
+	}, "trap")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("trap")
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rp.Output(0)) < 1 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// what a container runtime does to PID 1, only the helper gets it
+	syscall.Kill(os.Getpid(), syscall.SIGTERM)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	exitStatus, err := rp.Wait(ctx)
+	if err != nil || exitStatus.Code != 0 {
+		t.Fatalf("expected a clean exit, got %+v, %v", exitStatus, err)
+	}
+
+	// the last line can come after the exit
+	deadline = time.Now().Add(time.Second)
+	for len(rp.Output(0)) < 2 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if output := linesAsString(rp.Output(0)); output != "[stdout READY][stdout TERM]" {
+		t.Fatalf("expected [stdout READY][stdout TERM], got %s", output)
+	}
+
+	// stopped, not restarted
+	time.Sleep(500 * time.Millisecond)
+	if rp.IsRunning() || monitor.GetRestartState("trap").Restarts != 0 {
+		t.Fatalf("expected no restart")
+	}
+}
@@ -0,0 +1,204 @@
+// +build !windows
+
+package tests
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestReloadUnix(t *testing.T) {
+	const logID = "TestReloadUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	sleep := func(seconds string) contracts.ProcessTemplate {
+		return contracts.ProcessTemplate{
+			Executable: "sleep",
+			Args:       []string{seconds},
+		}
+	}
+
+	monitor := procMon.New()
+	monitor.SpawnWithTag(sleep("30"), "kept")
+	monitor.SpawnWithTag(sleep("30"), "changed")
+	monitor.SpawnWithTag(sleep("30"), "removed")
+	defer monitor.StopAllInParallel()
+
+	keptPID := monitor.GetProcess("kept").Details().ProcessID
+	changedPID := monitor.GetProcess("changed").Details().ProcessID
+
+	desired := map[string]contracts.ProcessTemplate{
+		"kept":    sleep("30"),
+		"changed": sleep("31"),
+		"added":   sleep("30"),
+	}
+
+	expected := []contracts.ReloadStep{
+		{Tag: "removed", Action: contracts.ReloadRemove},
+		{Tag: "changed", Action: contracts.ReloadRestart},
+		{Tag: "added", Action: contracts.ReloadAdd},
+		{Tag: "kept", Action: contracts.ReloadKeep},
+	}
+
+	// dry run
+	plan := monitor.PlanReload(desired)
+	if !sameSteps(plan.Steps, expected) {
+		t.Fatalf("bad plan %+v", plan.Steps)
+	}
+	if !monitor.GetProcess("removed").IsRunning() || len(monitor.GetAllTags()) != 3 {
+		t.Fatalf("the dry run changed something")
+	}
+
+	plan, err := monitor.Reload(desired)
+	if err != nil || !sameSteps(plan.Steps, expected) {
+		t.Fatalf("bad reload %+v, %v", plan.Steps, err)
+	}
+
+	tags := monitor.GetAllTags()
+	sort.Strings(tags)
+	if len(tags) != 3 || tags[0] != "added" || tags[1] != "changed" || tags[2] != "kept" {
+		t.Fatalf("bad tags %v", tags)
+	}
+
+	if monitor.GetProcess("kept").Details().ProcessID != keptPID {
+		t.Fatalf("unchanged program was restarted")
+	}
+
+	changed := monitor.GetProcess("changed").Details()
+	if changed.ProcessID == changedPID || !monitor.GetProcess("changed").IsRunning() {
+		t.Fatalf("changed program was not restarted")
+	}
+
+	if !monitor.GetProcess("added").IsRunning() {
+		t.Fatalf("added program is not running")
+	}
+
+	// nothing left to do
+	for _, step := range monitor.PlanReload(desired).Steps {
+		if step.Action != contracts.ReloadKeep {
+			t.Fatalf("expected only keeps, got %+v", step)
+		}
+	}
+}
+
+func TestReloadDependenciesUnix(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	monitor.SpawnAll(map[string]contracts.ProcessTemplate{
+		"db":  {Executable: "sleep", Args: []string{"30"}},
+		"web": {Executable: "sleep", Args: []string{"30"}, Requires: []string{"db"}},
+	})
+
+	events, unsubscribe := monitor.Events()
+	defer unsubscribe()
+
+	// `db` gets ready a while after it starts, `web` must wait for it and `worker` for `web`
+	desired := map[string]contracts.ProcessTemplate{
+		"db": {
+			Executable: "sh",
+			Args:       []string{"-c", "sleep 0.3; echo ready; sleep 30"},
+			Readiness:  contracts.HealthCheck{Type: contracts.HealthCheckOutput, Pattern: "ready"},
+		},
+		"web":    {Executable: "sleep", Args: []string{"31"}, Requires: []string{"db"}},
+		"worker": {Executable: "sleep", Args: []string{"30"}, Requires: []string{"web"}},
+	}
+
+	if _, err := monitor.Reload(desired); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if stopped := tagsOf(t, events, contracts.MonitorEventStopped, 2); !before(stopped, "web", "db") {
+		t.Fatalf("expected web stopped before db, got %v", stopped)
+	}
+
+	started := tagsOf(t, events, contracts.MonitorEventStarted, 3)
+	if !before(started, "db", "web") || !before(started, "web", "worker") {
+		t.Fatalf("expected db, web, worker started in order, got %v", started)
+	}
+
+	if gap := monitor.GetProcess("web").StartedAt().Sub(monitor.GetProcess("db").StartedAt()); gap < 250*time.Millisecond {
+		t.Fatalf("web started %s after db, before db was ready", gap)
+	}
+
+	// a broken desired set changes nothing
+	broken := map[string]contracts.ProcessTemplate{
+		"web": {Executable: "sleep", Args: []string{"32"}, Requires: []string{"missing"}},
+	}
+	webPID := monitor.GetProcess("web").Details().ProcessID
+
+	if _, err := monitor.Reload(broken); !errors.Is(err, contracts.ErrReloadFailed) {
+		t.Fatalf("expected ErrReloadFailed, got %v", err)
+	}
+	if monitor.GetProcess("web").Details().ProcessID != webPID || !monitor.GetProcess("db").IsRunning() {
+		t.Fatalf("a broken reload changed something")
+	}
+}
+
+func sameSteps(steps []contracts.ReloadStep, expected []contracts.ReloadStep) bool {
+	if len(steps) != len(expected) {
+		return false
+	}
+
+	for i := range steps {
+		if steps[i] != expected[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestReloadStopFailedUnix(t *testing.T) {
+	const logID = "TestReloadStopFailedUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+	monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "trap '' USR1; sleep 0.2; echo ready; sleep 30"},
+		StopStrategy: contracts.StopStrategy{Steps: []contracts.StopStep{
+			{Signal: "SIGUSR1", GracePeriod: 200 * time.Millisecond},
+		}},
+		Readiness: contracts.HealthCheck{
+			Type:     contracts.HealthCheckOutput,
+			Pattern:  "^ready$",
+			Interval: 10 * time.Millisecond,
+		},
+	}, "stubborn")
+	rp := monitor.GetProcess("stubborn")
+	defer rp.Signal("SIGKILL")
+
+	// the signal is ignored once the trap is set
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := monitor.WaitReady(ctx, "stubborn"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// the process ignores its stop
+	plan, err := monitor.Reload(map[string]contracts.ProcessTemplate{})
+	if !errors.Is(err, contracts.ErrReloadFailed) {
+		t.Fatalf("expected ErrReloadFailed, got %v", err)
+	}
+	if len(plan.Steps) != 1 || plan.Steps[0].Action != contracts.ReloadRemove || len(plan.Steps[0].Error) == 0 {
+		t.Fatalf("bad plan %+v", plan.Steps)
+	}
+
+	if tags := monitor.GetAllTags(); len(tags) != 1 || tags[0] != "stubborn" || monitor.GetProcess("stubborn") != rp {
+		t.Fatalf("expected the tag still monitored, got %v", tags)
+	}
+	if !rp.IsRunning() {
+		t.Fatalf("expected the process still running")
+	}
+}
@@ -0,0 +1,81 @@
+// +build linux
+
+package tests
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestResourceLimitsLinux(t *testing.T) {
+	const logID = "TestResourceLimitsLinux"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	// the limits are set before the first instruction, the shell doesn't have to wait for them
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "ulimit -n; ulimit -c; sleep 30"},
+		ResourceLimits: contracts.ResourceLimits{
+			OpenFiles: &contracts.ResourceLimit{Soft: 64, Hard: 128},
+			CoreSize:  &contracts.ResourceLimit{Soft: 0, Hard: 0},
+		},
+	}, "limited")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("limited")
+
+	limits := rp.Details().Limits
+	if limits.OpenFiles == nil || *limits.OpenFiles != (contracts.ResourceLimit{Soft: 64, Hard: 128}) {
+		t.Fatalf("bad open files limit %+v", limits.OpenFiles)
+	}
+
+	if limits.CoreSize == nil || *limits.CoreSize != (contracts.ResourceLimit{Soft: 0, Hard: 0}) {
+		t.Fatalf("bad core size limit %+v", limits.CoreSize)
+	}
+
+	// inherited
+	if limits.CPUTime == nil || limits.AddressSpace == nil || limits.Processes == nil {
+		t.Fatalf("missing limits %+v", limits)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rp.Output(0)) < 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if output := linesAsString(rp.Output(0)); output != "[stdout 64][stdout 0]" {
+		t.Fatalf("expected the limits seen by the process, got %q", output)
+	}
+}
+
+func TestResourceLimitsFailLinux(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		ResourceLimits: contracts.ResourceLimits{
+			OpenFiles: &contracts.ResourceLimit{Soft: 128, Hard: 64},
+		},
+	}, "bad-limits")
+	if err == nil || !strings.Contains(err.Error(), "openFiles") {
+		t.Fatalf("expected the start to fail, got %v", err)
+	}
+
+	if monitor.GetProcess("bad-limits").IsRunning() {
+		t.Fatalf("should not run without its limits")
+	}
+}
@@ -0,0 +1,161 @@
+// +build !windows
+
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	"github.com/codemodify/systemkit-processes/helpers"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestRestartOnFailureUnix(t *testing.T) {
+	const logID = "TestRestartOnFailureUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	tempDir, err := ioutil.TempDir("", logID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	runsFile := filepath.Join(tempDir, "runs")
+
+	monitor := procMon.New()
+	monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "echo run >> " + runsFile + "; exit 3"},
+		RestartPolicy: contracts.RestartPolicy{
+			Mode:        contracts.RestartOnFailure,
+			MaxRestarts: 2,
+			Window:      1 * time.Minute,
+		},
+	})
+
+	time.Sleep(5 * time.Second)
+
+	data, _ := ioutil.ReadFile(runsFile)
+	runs := strings.Count(string(data), "run")
+	if runs != 3 {
+		t.Fatalf("expected 3 runs (1 start + 2 restarts), got %d", runs)
+	}
+}
+
+func TestRestartAlwaysStoppedUnix(t *testing.T) {
+	const logID = "TestRestartAlwaysStoppedUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+		RestartPolicy: contracts.RestartPolicy{
+			Mode: contracts.RestartAlways,
+		},
+	})
+
+	monitor.Stop(processTag)
+	time.Sleep(2 * time.Second)
+
+	if monitor.GetProcess(processTag).IsRunning() {
+		t.Fatal("stopped process should not be restarted")
+	}
+}
+
+func TestRestartUnlessStoppedUnix(t *testing.T) {
+	const logID = "TestRestartUnlessStoppedUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	templates := map[string]contracts.ProcessTemplate{
+		"always": {
+			Executable:    "sleep",
+			Args:          []string{"30"},
+			RestartPolicy: contracts.RestartPolicy{Mode: contracts.RestartAlways},
+		},
+		"unless": {
+			Executable:    "sleep",
+			Args:          []string{"30"},
+			RestartPolicy: contracts.RestartPolicy{Mode: contracts.RestartUnlessStopped},
+		},
+	}
+	if err := monitor.SpawnAll(templates); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	monitor.Stop("always")
+	monitor.Stop("unless")
+
+	// `always` comes back, `unless` stays stopped, even when spawned again
+	if err := monitor.StartAll(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := monitor.SpawnWithTag(templates["unless"], "unless"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !monitor.GetProcess("always").IsRunning() || monitor.GetProcess("unless").IsRunning() {
+		t.Fatalf("expected only always running")
+	}
+
+	// until it is started again
+	if err := monitor.Start("unless"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	monitor.StopAll()
+
+	if err := monitor.StartAll(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !monitor.GetProcess("unless").IsRunning() {
+		t.Fatalf("expected unless running after a start and a stop of all")
+	}
+}
+
+func TestRestartBackoffUnix(t *testing.T) {
+	const logID = "TestRestartBackoffUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "exit 1"},
+		RestartPolicy: contracts.RestartPolicy{
+			Mode: contracts.RestartAlways,
+			Backoff: contracts.BackoffPolicy{
+				InitialDelay: 10 * time.Second,
+				Multiplier:   2,
+				MaxDelay:     1 * time.Minute,
+			},
+		},
+	})
+	defer monitor.Stop(processTag)
+
+	time.Sleep(2 * time.Second)
+
+	restartState := monitor.GetRestartState(processTag)
+	logging.Infof("%s: %s", logID, helpers.AsJSONString(restartState))
+
+	if !restartState.Pending || restartState.CurrentDelay != 10*time.Second || restartState.BackoffAttempt != 1 {
+		t.Fatalf("expected a restart pending in 10s, got %+v", restartState)
+	}
+
+	monitor.Stop(processTag)
+	if monitor.GetRestartState(processTag).Pending {
+		t.Fatal("stop should cancel the pending restart")
+	}
+}
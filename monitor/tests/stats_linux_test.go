@@ -0,0 +1,100 @@
+// +build linux
+
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestStatsLinux(t *testing.T) {
+	const logID = "TestStatsLinux"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "while :; do :; done"},
+	}, "busy")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rp := monitor.GetProcess("busy")
+
+	time.Sleep(300 * time.Millisecond)
+	stats, err := rp.Stats()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if stats.ProcessID != rp.Details().ProcessID {
+		t.Fatalf("bad PID %d", stats.ProcessID)
+	}
+
+	if stats.MemoryRSS == 0 || stats.MemoryVSZ < stats.MemoryRSS || stats.Threads < 1 || stats.OpenFDs < 1 {
+		t.Fatalf("bad stats %+v", stats)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	stats, err = rp.Stats()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if stats.CPUUser+stats.CPUSystem == 0 || stats.CPUPercent < 20 {
+		t.Fatalf("expected a busy CPU, got %+v", stats)
+	}
+
+	monitor.Stop("busy")
+
+	if _, err := rp.Stats(); !errors.Is(err, contracts.ErrProcessDoesNotExist) {
+		t.Fatalf("expected ErrProcessDoesNotExist, got %v", err)
+	}
+}
+
+func TestStatsSamplerLinux(t *testing.T) {
+	monitor := procMon.New()
+	defer monitor.StopAll()
+
+	err := monitor.SpawnWithTag(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"30"},
+	}, "sleeper")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	monitor.SampleStats(100*time.Millisecond, 3)
+	time.Sleep(650 * time.Millisecond)
+	monitor.SampleStats(0, 0)
+
+	history := monitor.GetStats("sleeper")
+	if len(history) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(history))
+	}
+
+	for i := 1; i < len(history); i++ {
+		if !history[i].Time.After(history[i-1].Time) {
+			t.Fatalf("samples out of order %v", history)
+		}
+	}
+
+	if history[2].CPUPercent > 20 {
+		t.Fatalf("sleep should be idle, got %v", history[2].CPUPercent)
+	}
+
+	monitor.RemoveFromMonitor("sleeper")
+	if len(monitor.GetStats("sleeper")) != 0 {
+		t.Fatalf("history should be gone with the tag")
+	}
+}
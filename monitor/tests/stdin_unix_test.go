@@ -0,0 +1,117 @@
+// +build !windows
+
+package tests
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestStdinUnix(t *testing.T) {
+	const logID = "TestStdinUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable:  "cat",
+		StdinData:   []byte("hello\n"),
+		StdinReader: strings.NewReader("from reader\n"),
+		StdinOpen:   true,
+	})
+	rp := monitor.GetProcess(processTag)
+
+	linesSync := sync.Mutex{}
+	lines := []string{}
+	rp.OnStdOut(func(params interface{}, outputData []byte) {
+		linesSync.Lock()
+		defer linesSync.Unlock()
+
+		lines = append(lines, string(outputData))
+	}, nil)
+
+	if _, err := rp.Stdin().Write([]byte("world\n")); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	rp.Stdin().Close() // `cat` sees EOF and exits
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := rp.Wait(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	time.Sleep(100 * time.Millisecond) // let the reader catch up
+
+	linesSync.Lock()
+	defer linesSync.Unlock()
+
+	if strings.Join(lines, ",") != "hello,from reader,world" {
+		t.Fatalf("bad output: %v", lines)
+	}
+}
+
+func TestStdinNotAvailableUnix(t *testing.T) {
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sleep",
+		Args:       []string{"1"},
+	})
+	defer monitor.Stop(processTag)
+
+	if _, err := monitor.GetProcess(processTag).Stdin().Write([]byte("x")); err != contracts.ErrStdinNotAvailable {
+		t.Fatalf("expected ErrStdinNotAvailable, got %v", err)
+	}
+}
+
+func TestStdinFilesClosedUnix(t *testing.T) {
+	folder := t.TempDir()
+	stdinPath := filepath.Join(folder, "stdin-file")
+	readerPath := filepath.Join(folder, "stdin-reader")
+	ioutil.WriteFile(stdinPath, []byte("from file\n"), 0644)
+	ioutil.WriteFile(readerPath, []byte("from reader\n"), 0644)
+
+	reader, err := os.Open(readerPath)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer reader.Close()
+
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable:  "cat",
+		StdinFile:   stdinPath,
+		StdinReader: reader,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := monitor.GetProcess(processTag).Wait(ctx); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// opened here, closed here
+	if count := openCount(stdinPath); count != 0 {
+		t.Fatalf("expected %s closed, open %d times", stdinPath, count)
+	}
+
+	// the caller's, still open
+	if _, err := reader.Stat(); err != nil {
+		t.Fatalf("expected the reader open, %s", err.Error())
+	}
+}
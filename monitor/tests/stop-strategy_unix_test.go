@@ -0,0 +1,42 @@
+// +build !windows
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	logging "github.com/codemodify/systemkit-logging"
+
+	"github.com/codemodify/systemkit-processes/contracts"
+	procMon "github.com/codemodify/systemkit-processes/monitor"
+)
+
+func TestStopStrategyUnix(t *testing.T) {
+	const logID = "TestStopStrategyUnix"
+
+	logging.Debugf("%s: START", logID)
+
+	monitor := procMon.New()
+
+	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
+		Executable: "sh",
+		Args:       []string{"-c", "trap '' USR2; trap 'exit 5' QUIT; while :; do sleep 0.1; done"},
+		StopStrategy: contracts.StopStrategy{
+			Steps: []contracts.StopStep{
+				{Command: []string{"kill", "-USR2", "$MAINPID"}, GracePeriod: 500 * time.Millisecond}, // ignored by the trap
+				{Signal: "SIGQUIT", GracePeriod: 2 * time.Second},
+			},
+		},
+	})
+
+	time.Sleep(200 * time.Millisecond) // let `sh` install the trap
+
+	if err := monitor.Stop(processTag); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if monitor.GetProcess(processTag).ExitCode() != 5 {
+		t.Fatalf("expected the SIGQUIT trap to exit with 5, got %d", monitor.GetProcess(processTag).ExitCode())
+	}
+}
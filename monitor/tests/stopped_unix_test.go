@@ -25,8 +25,8 @@ func TestStoppedUnix(t *testing.T) {
 	processTag, _ := monitor.Spawn(contracts.ProcessTemplate{
 		Executable: "vim",
 	})
-	monitor.GetProcess(processTag).OnStop(func(params interface{}) {
-		logging.Debugf("%s: OnStop()", logID)
+	monitor.GetProcess(processTag).OnStop(func(params interface{}, exitStatus contracts.ExitStatus) {
+		logging.Debugf("%s: OnStop(), exit code %d", logID, exitStatus.Code)
 		wg.Done()
 	}, nil)
 
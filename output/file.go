@@ -0,0 +1,11 @@
+package output
+
+import (
+	"github.com/codemodify/systemkit-processes/contracts"
+	"github.com/codemodify/systemkit-processes/internal"
+)
+
+// NewFile - a rotating file for the `OnStdOut`/`OnStdErr` hooks, `rp.OnStdOut(sink.OnOutput, nil)`
+func NewFile(config contracts.OutputFile) (contracts.OutputSink, error) {
+	return internal.NewOutputFile(config)
+}